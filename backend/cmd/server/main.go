@@ -14,7 +14,10 @@ import (
 	"subahan-billing-backend/internal/cache"
 	"subahan-billing-backend/internal/config"
 	api "subahan-billing-backend/internal/http"
+	"subahan-billing-backend/internal/invoice"
 	"subahan-billing-backend/internal/jobs"
+	"subahan-billing-backend/internal/mail"
+	"subahan-billing-backend/internal/media"
 	"subahan-billing-backend/internal/migrations"
 	"subahan-billing-backend/internal/store"
 )
@@ -40,10 +43,29 @@ func main() {
 	}
 
 	cache := cache.New()
-	store := store.New(pool)
-	jobs.StartItemCleanup(ctx, store)
+	store := store.New(pool, store.Options{
+		AllowNegativeStock: cfg.AllowNegativeStock,
+		ItemIDPrefix:       cfg.ItemIDPrefix,
+		ItemIDWidth:        cfg.ItemIDWidth,
+		CleanupWindow:      cfg.ItemRestoreWindow,
+		PriceOverrideUsers: cfg.PriceOverrideUsers,
+		Location:           cfg.Location,
+	})
+	files, err := media.NewLocalStorage(cfg.MediaDir)
+	if err != nil {
+		log.Fatalf("media storage error: %v", err)
+	}
+	jobs.StartItemCleanup(ctx, store, files)
+	jobs.StartReorderList(ctx, store, cfg.ReorderWindowDays, cfg.ReorderLeadDays)
+
+	var mailer *invoice.Mailer
+	if cfg.SMTPHost != "" {
+		sender := mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPFrom)
+		mailer = invoice.NewMailer(store, sender, cfg.DeliveryMaxAttempts)
+		jobs.StartDeliveryRetry(ctx, mailer)
+	}
 
-	server := api.NewServer(&cfg, store, cache)
+	server := api.NewServer(&cfg, store, cache, mailer, files)
 	httpServer := &http.Server{
 		Addr:              ":" + cfg.Port,
 		Handler:           server.Router(),
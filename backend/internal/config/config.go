@@ -3,6 +3,11 @@ package config
 import (
 	"errors"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	// Time zones resolve even where the host has no zoneinfo
+	_ "time/tzdata"
 )
 
 type Config struct {
@@ -12,6 +17,41 @@ type Config struct {
 	AdminUser   string
 	AdminPass   string
 	CORSOrigin  string
+
+	// SMTP settings for emailing invoices. Delivery is disabled when SMTPHost is empty.
+	SMTPHost            string
+	SMTPPort            string
+	SMTPUser            string
+	SMTPPass            string
+	SMTPFrom            string
+	DeliveryMaxAttempts int
+
+	// AllowNegativeStock lets bills sell more than is on hand.
+	AllowNegativeStock bool
+	// ReorderWindowDays is how far back the reorder job averages sales;
+	// ReorderLeadDays is how many days of sales an item without its own
+	// reorder point should still have on hand.
+	ReorderWindowDays int
+	ReorderLeadDays   int
+
+	// Location is the business time zone; report dates and days start
+	// and end in it.
+	Location *time.Location
+
+	// PriceOverrideUsers may bill at a hand-typed price below an item's
+	// minimum margin.
+	PriceOverrideUsers []string
+
+	// ItemIDPrefix and ItemIDWidth shape generated item IDs, e.g. ITEM001.
+	// Categories may set their own prefix.
+	ItemIDPrefix string
+	ItemIDWidth  int
+
+	// ItemRestoreWindow is how long a deleted item stays in the trash.
+	ItemRestoreWindow time.Duration
+
+	// MediaDir is where item images are stored on local disk.
+	MediaDir string
 }
 
 func Load() (Config, error) {
@@ -22,6 +62,12 @@ func Load() (Config, error) {
 		AdminUser:   os.Getenv("ADMIN_USERNAME"),
 		AdminPass:   os.Getenv("ADMIN_PASSWORD"),
 		CORSOrigin:  os.Getenv("CORS_ORIGIN"),
+		MediaDir:    os.Getenv("MEDIA_DIR"),
+		SMTPHost:    os.Getenv("SMTP_HOST"),
+		SMTPPort:    os.Getenv("SMTP_PORT"),
+		SMTPUser:    os.Getenv("SMTP_USERNAME"),
+		SMTPPass:    os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:    os.Getenv("SMTP_FROM"),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -39,6 +85,106 @@ func Load() (Config, error) {
 	if cfg.CORSOrigin == "" {
 		cfg.CORSOrigin = "*"
 	}
+	if cfg.MediaDir == "" {
+		cfg.MediaDir = "media"
+	}
+
+	if cfg.SMTPPort == "" {
+		cfg.SMTPPort = "587"
+	}
+	if cfg.SMTPFrom == "" {
+		cfg.SMTPFrom = cfg.SMTPUser
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom == "" {
+		return cfg, errors.New("SMTP_FROM or SMTP_USERNAME is required when SMTP_HOST is set")
+	}
+	cfg.DeliveryMaxAttempts = 5
+	if v := os.Getenv("DELIVERY_MAX_ATTEMPTS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			return cfg, errors.New("DELIVERY_MAX_ATTEMPTS must be a positive integer")
+		}
+		cfg.DeliveryMaxAttempts = parsed
+	}
+
+	cfg.AllowNegativeStock = true
+	if v := os.Getenv("ALLOW_NEGATIVE_STOCK"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, errors.New("ALLOW_NEGATIVE_STOCK must be true or false")
+		}
+		cfg.AllowNegativeStock = parsed
+	}
+	cfg.ReorderWindowDays = 30
+	if v := os.Getenv("REORDER_WINDOW_DAYS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 365 {
+			return cfg, errors.New("REORDER_WINDOW_DAYS must be between 1 and 365")
+		}
+		cfg.ReorderWindowDays = parsed
+	}
+	cfg.ReorderLeadDays = 7
+	if v := os.Getenv("REORDER_LEAD_DAYS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 || parsed > 365 {
+			return cfg, errors.New("REORDER_LEAD_DAYS must be between 0 and 365")
+		}
+		cfg.ReorderLeadDays = parsed
+	}
+
+	cfg.Location = time.UTC
+	if v := os.Getenv("BUSINESS_TIMEZONE"); v != "" {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			return cfg, errors.New("BUSINESS_TIMEZONE must be an IANA time zone, e.g. Asia/Kuwait")
+		}
+		cfg.Location = loc
+	}
+
+	for _, user := range strings.Split(os.Getenv("PRICE_OVERRIDE_USERS"), ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			cfg.PriceOverrideUsers = append(cfg.PriceOverrideUsers, user)
+		}
+	}
+
+	cfg.ItemIDPrefix = "ITEM"
+	if v := os.Getenv("ITEM_ID_PREFIX"); v != "" {
+		if !ValidItemIDPrefix(v) {
+			return cfg, errors.New("ITEM_ID_PREFIX must be 1 to 20 letters and numbers")
+		}
+		cfg.ItemIDPrefix = v
+	}
+	cfg.ItemIDWidth = 3
+	if v := os.Getenv("ITEM_ID_WIDTH"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 12 {
+			return cfg, errors.New("ITEM_ID_WIDTH must be between 1 and 12")
+		}
+		cfg.ItemIDWidth = parsed
+	}
+	cfg.ItemRestoreWindow = 24 * time.Hour
+	if v := os.Getenv("ITEM_RESTORE_WINDOW"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed < time.Minute {
+			return cfg, errors.New("ITEM_RESTORE_WINDOW must be a duration of at least 1m, e.g. 24h")
+		}
+		cfg.ItemRestoreWindow = parsed
+	}
 
 	return cfg, nil
 }
+
+// ValidItemIDPrefix reports whether prefix is 1 to 20 letters and numbers,
+// keeping generated item IDs within what the item handlers accept. It
+// checks ITEM_ID_PREFIX and category prefixes alike.
+func ValidItemIDPrefix(prefix string) bool {
+	if prefix == "" || len(prefix) > 20 {
+		return false
+	}
+	for _, ch := range prefix {
+		if (ch < '0' || ch > '9') && (ch < 'A' || ch > 'Z') && (ch < 'a' || ch > 'z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"subahan-billing-backend/internal/store"
+)
+
+// normalizeItemCodes validates barcodes and SKUs from an item payload. A
+// missing kind is inferred: 12 or 13 digits is EAN-13 (UPC-A gets its
+// leading zero), anything else is Code 128.
+func normalizeItemCodes(codes []store.ItemCode) ([]store.ItemCode, error) {
+	if codes == nil {
+		return nil, nil
+	}
+	seen := map[string]bool{}
+	normalized := make([]store.ItemCode, 0, len(codes))
+	for _, c := range codes {
+		code := strings.TrimSpace(c.Code)
+		kind := strings.ToLower(strings.TrimSpace(c.Kind))
+		if code == "" {
+			return nil, errors.New("codes must not be empty")
+		}
+		if kind == "" {
+			kind = store.CodeKindCode128
+			if isDigits(code) && (len(code) == 12 || len(code) == 13) {
+				kind = store.CodeKindEAN13
+			}
+		}
+
+		switch kind {
+		case store.CodeKindEAN13:
+			if len(code) == 12 && isDigits(code) {
+				code = "0" + code
+			}
+			if !validEAN13(code) {
+				return nil, fmt.Errorf("%s is not a valid EAN-13 barcode", code)
+			}
+		case store.CodeKindCode128, store.CodeKindSKU:
+			if len(code) > 80 {
+				return nil, fmt.Errorf("%s is longer than 80 characters", code)
+			}
+			for _, ch := range code {
+				if ch < 0x20 || ch > 0x7e {
+					return nil, fmt.Errorf("%s contains characters that cannot be encoded in Code 128", code)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown code kind %q", c.Kind)
+		}
+
+		var supplier *string
+		if c.Supplier != nil && strings.TrimSpace(*c.Supplier) != "" {
+			trimmed := strings.TrimSpace(*c.Supplier)
+			supplier = &trimmed
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("code %s is listed more than once", code)
+		}
+		seen[code] = true
+		normalized = append(normalized, store.ItemCode{Code: code, Kind: kind, Supplier: supplier})
+	}
+	return normalized, nil
+}
+
+// validEAN13 checks length and the GS1 mod-10 check digit.
+func validEAN13(code string) bool {
+	if len(code) != 13 || !isDigits(code) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(code[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := (10 - sum%10) % 10
+	return check == int(code[12]-'0')
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,11 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
@@ -22,8 +24,18 @@ func (s *Server) handleCreateBill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bill, err := s.Store.CreateBill(r.Context(), input)
+	bill, err := s.Store.CreateBill(r.Context(), input, currentUser(r))
 	if err != nil {
+		var stockErr *store.StockError
+		if errors.As(err, &stockErr) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		var marginErr *store.MarginError
+		if errors.As(err, &marginErr) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -46,7 +58,13 @@ func (s *Server) handleListBills(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	bills, err := s.Store.ListBills(r.Context(), limit, offset)
+	filter, err := parseBillFilter(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bills, err := s.Store.ListBills(r.Context(), filter, limit, offset)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list bills")
 		return
@@ -58,7 +76,7 @@ func (s *Server) handleGetBill(w http.ResponseWriter, r *http.Request) {
 	billID := chi.URLParam(r, "billId")
 	bill, err := s.Store.GetBill(r.Context(), billID)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "bill not found")
+		writeStoreError(w, err, "bill not found", "failed to load bill")
 		return
 	}
 	writeJSON(w, http.StatusOK, bill)
@@ -76,12 +94,22 @@ func (s *Server) handleUpdateBill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bill, err := s.Store.UpdateBill(r.Context(), billID, input)
+	bill, err := s.Store.UpdateBill(r.Context(), billID, input, currentUser(r))
 	if err != nil {
 		if err == store.ErrNotFound {
 			writeError(w, http.StatusNotFound, "bill or item not found")
 			return
 		}
+		var stockErr *store.StockError
+		if errors.As(err, &stockErr) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		var marginErr *store.MarginError
+		if errors.As(err, &marginErr) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -91,13 +119,23 @@ func (s *Server) handleUpdateBill(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleDeleteBill(w http.ResponseWriter, r *http.Request) {
 	billID := chi.URLParam(r, "billId")
-	if err := s.Store.DeleteBill(r.Context(), billID); err != nil {
-		if err == store.ErrNotFound {
-			writeError(w, http.StatusNotFound, "bill not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "failed to delete bill")
+	if err := s.Store.DeleteBill(r.Context(), billID, currentUser(r)); err != nil {
+		writeStoreError(w, err, "bill not found", "failed to delete bill")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// parseBillFilter reads the filters shared by the bill list endpoints:
+// from/to dates (inclusive) and a customer name fragment.
+func parseBillFilter(r *http.Request, loc *time.Location) (store.BillFilter, error) {
+	period, err := parseReportRange(r, loc)
+	if err != nil {
+		return store.BillFilter{}, err
+	}
+	return store.BillFilter{
+		From:     period.From,
+		To:       period.To,
+		Customer: strings.TrimSpace(r.URL.Query().Get("customer")),
+	}, nil
+}
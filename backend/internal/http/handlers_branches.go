@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListBranches(w http.ResponseWriter, r *http.Request) {
+	branches, err := s.Store.ListBranches(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load branches")
+		return
+	}
+	writeJSON(w, http.StatusOK, branches)
+}
+
+func (s *Server) handleCreateBranch(w http.ResponseWriter, r *http.Request) {
+	var input store.BranchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	branch, err := s.Store.CreateBranch(r.Context(), input)
+	if err != nil {
+		writeBranchError(w, err, "failed to create branch")
+		return
+	}
+	writeJSON(w, http.StatusCreated, branch)
+}
+
+func (s *Server) handleUpdateBranch(w http.ResponseWriter, r *http.Request) {
+	var input store.BranchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	branch, err := s.Store.UpdateBranch(r.Context(), chi.URLParam(r, "branchId"), input)
+	if err != nil {
+		writeBranchError(w, err, "failed to update branch")
+		return
+	}
+	writeJSON(w, http.StatusOK, branch)
+}
+
+func (s *Server) handleDeleteBranch(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteBranch(r.Context(), chi.URLParam(r, "branchId")); err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "branch has bills")
+			return
+		}
+		writeStoreError(w, err, "branch not found", "failed to delete branch")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func writeBranchError(w http.ResponseWriter, err error, fallback string) {
+	if err == store.ErrConflict {
+		writeError(w, http.StatusConflict, "a branch with this name already exists")
+		return
+	}
+	writeStoreError(w, err, "branch not found", fallback)
+}
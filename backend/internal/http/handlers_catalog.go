@@ -0,0 +1,201 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/config"
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.Store.ListCategories(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load categories")
+		return
+	}
+	writeJSON(w, http.StatusOK, categories)
+}
+
+func (s *Server) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
+	var input store.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateCategory(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	category, err := s.Store.CreateCategory(r.Context(), input)
+	if err != nil {
+		writeCategoryError(w, err, "failed to create category")
+		return
+	}
+	writeJSON(w, http.StatusCreated, category)
+}
+
+func (s *Server) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryId")
+	var input store.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateCategory(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	category, err := s.Store.UpdateCategory(r.Context(), categoryID, input)
+	if err != nil {
+		writeCategoryError(w, err, "failed to update category")
+		return
+	}
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, category)
+}
+
+// handleSetCategoryMinMargin sets the margin hand-typed bill prices must
+// keep on items in the branch without their own; null clears it.
+func (s *Server) handleSetCategoryMinMargin(w http.ResponseWriter, r *http.Request) {
+	var req minMarginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if m := req.MinMarginPercent; m != nil && (*m < 0 || *m >= 100) {
+		writeError(w, http.StatusBadRequest, "minMarginPercent must be at least 0 and below 100")
+		return
+	}
+
+	category, err := s.Store.SetCategoryMinMargin(r.Context(), chi.URLParam(r, "categoryId"), req.MinMarginPercent)
+	if err != nil {
+		writeCategoryError(w, err, "failed to save minimum margin")
+		return
+	}
+	writeJSON(w, http.StatusOK, category)
+}
+
+func (s *Server) handleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryId")
+	if err := s.Store.DeleteCategory(r.Context(), categoryID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			writeError(w, http.StatusNotFound, "category not found")
+		case store.ErrConflict:
+			writeError(w, http.StatusConflict, "category has subcategories")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to delete category")
+		}
+		return
+	}
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func validateCategory(input *store.CategoryInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "name is required"
+	}
+	if strings.TrimSpace(input.ArabicName) == "" {
+		return "arabicName is required"
+	}
+	if input.MinMarginPercent != nil && (*input.MinMarginPercent < 0 || *input.MinMarginPercent >= 100) {
+		return "minMarginPercent must be at least 0 and below 100"
+	}
+	if input.ItemIDPrefix != nil {
+		prefix := strings.TrimSpace(*input.ItemIDPrefix)
+		if prefix != "" && !config.ValidItemIDPrefix(prefix) {
+			return "itemIdPrefix must be 1 to 20 letters and numbers"
+		}
+		input.ItemIDPrefix = &prefix
+	}
+	return ""
+}
+
+func writeCategoryError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case store.ErrConflict:
+		writeError(w, http.StatusConflict, "a category with this name already exists here")
+	case store.ErrInvalidReference:
+		writeError(w, http.StatusBadRequest, "parent category not found")
+	case store.ErrCategoryCycle:
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeStoreError(w, err, "category not found", fallback)
+	}
+}
+
+func (s *Server) handleListBrands(w http.ResponseWriter, r *http.Request) {
+	brands, err := s.Store.ListBrands(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load brands")
+		return
+	}
+	writeJSON(w, http.StatusOK, brands)
+}
+
+func (s *Server) handleCreateBrand(w http.ResponseWriter, r *http.Request) {
+	var input store.BrandInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	brand, err := s.Store.CreateBrand(r.Context(), input)
+	if err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "brand already exists")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create brand")
+		return
+	}
+	writeJSON(w, http.StatusCreated, brand)
+}
+
+func (s *Server) handleUpdateBrand(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandId")
+	var input store.BrandInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	brand, err := s.Store.UpdateBrand(r.Context(), brandID, input)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			writeError(w, http.StatusNotFound, "brand not found")
+		case store.ErrConflict:
+			writeError(w, http.StatusConflict, "brand already exists")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to update brand")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, brand)
+}
+
+func (s *Server) handleDeleteBrand(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandId")
+	if err := s.Store.DeleteBrand(r.Context(), brandID); err != nil {
+		writeError(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
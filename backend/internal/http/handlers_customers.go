@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListCustomers(w http.ResponseWriter, r *http.Request) {
+	customers, err := s.Store.ListCustomers(r.Context(), strings.TrimSpace(r.URL.Query().Get("q")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load customers")
+		return
+	}
+	writeJSON(w, http.StatusOK, customers)
+}
+
+func (s *Server) handleGetCustomer(w http.ResponseWriter, r *http.Request) {
+	customer, err := s.Store.GetCustomer(r.Context(), chi.URLParam(r, "customerId"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "customer not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, customer)
+}
+
+func (s *Server) handleCreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var input store.CustomerInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateCustomer(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	customer, err := s.Store.CreateCustomer(r.Context(), input)
+	if err != nil {
+		writeCustomerError(w, err, "failed to create customer")
+		return
+	}
+	writeJSON(w, http.StatusCreated, customer)
+}
+
+func (s *Server) handleUpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	var input store.CustomerInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateCustomer(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	customer, err := s.Store.UpdateCustomer(r.Context(), chi.URLParam(r, "customerId"), input)
+	if err != nil {
+		writeCustomerError(w, err, "failed to update customer")
+		return
+	}
+	writeJSON(w, http.StatusOK, customer)
+}
+
+func (s *Server) handleDeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteCustomer(r.Context(), chi.URLParam(r, "customerId")); err != nil {
+		writeError(w, http.StatusNotFound, "customer not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// validateCustomer trims the input and blanks empty optional fields.
+func validateCustomer(input *store.CustomerInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "name is required"
+	}
+	for _, field := range []**string{&input.Email, &input.Phone, &input.PriceListID} {
+		if *field != nil {
+			if v := strings.TrimSpace(**field); v == "" {
+				*field = nil
+			} else {
+				*field = &v
+			}
+		}
+	}
+	if input.Email != nil {
+		if _, err := mail.ParseAddress(*input.Email); err != nil {
+			return "email is not a valid address"
+		}
+	}
+	return ""
+}
+
+func writeCustomerError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case store.ErrConflict:
+		writeError(w, http.StatusConflict, "a customer with this name already exists")
+	case store.ErrInvalidReference:
+		writeError(w, http.StatusBadRequest, "price list not found")
+	default:
+		writeStoreError(w, err, "customer not found", fallback)
+	}
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	netmail "net/mail"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+type sendBillRequest struct {
+	To string `json:"to"`
+}
+
+func (s *Server) handleSendBill(w http.ResponseWriter, r *http.Request) {
+	if s.Mailer == nil {
+		writeError(w, http.StatusServiceUnavailable, "email delivery is not configured")
+		return
+	}
+
+	billID := chi.URLParam(r, "billId")
+	var req sendBillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	recipient := strings.TrimSpace(req.To)
+	if recipient == "" {
+		bill, err := s.Store.GetBill(r.Context(), billID)
+		if err != nil {
+			writeStoreError(w, err, "bill not found", "failed to load bill")
+			return
+		}
+		if bill.CustomerEmail != nil {
+			recipient = strings.TrimSpace(*bill.CustomerEmail)
+		}
+	}
+	if recipient == "" {
+		writeError(w, http.StatusBadRequest, "recipient email is required")
+		return
+	}
+	addr, err := netmail.ParseAddress(recipient)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid recipient email")
+		return
+	}
+
+	delivery, err := s.Mailer.Send(r.Context(), billID, addr.Address, currentUser(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "bill not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to send invoice")
+		return
+	}
+
+	status := http.StatusOK
+	switch delivery.Status {
+	case "retrying":
+		status = http.StatusAccepted
+	case "failed":
+		status = http.StatusBadGateway
+	}
+	writeJSON(w, status, delivery)
+}
+
+func (s *Server) handleListBillDeliveries(w http.ResponseWriter, r *http.Request) {
+	billID := chi.URLParam(r, "billId")
+	deliveries, err := s.Store.ListBillDeliveries(r.Context(), billID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "bill not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load deliveries")
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"subahan-billing-backend/internal/invoice"
+	"subahan-billing-backend/internal/sheet"
+	"subahan-billing-backend/internal/store"
+)
+
+var itemExportHeader = []any{
+	"itemId", "name", "arabicName", "buyingPrice", "sellingPrice", "unit", "isWireBox",
+	"purchasePercentage", "sellPercentage", "categoryId", "brandId", "barcodes", "skus", "supplier",
+	"category", "brand", "createdAt", "updatedAt", "deletedAt",
+}
+
+var billExportHeader = []any{
+	"billId", "invoiceNumber", "customer", "customerEmail", "totalAmount", "lineCount", "createdAt", "updatedAt",
+}
+
+var billLineExportHeader = []any{
+	"billId", "invoiceNumber", "billDate", "customer", "itemId", "itemName", "arabicName", "unit",
+	"quantity", "unitPrice", "lineTotal",
+}
+
+// handleExportItems streams the catalog with the same filters as GET /items.
+// The leading columns match the import format so the file can be edited and
+// uploaded again.
+func (s *Server) handleExportItems(w http.ResponseWriter, r *http.Request) {
+	filter := parseItemFilter(r)
+	s.streamExport(w, r, "items", itemExportHeader, func(write func([]any) error) error {
+		return s.Store.ExportItems(r.Context(), filter, func(row store.ItemExportRow) error {
+			return write([]any{
+				row.ItemID, row.Name, row.ArabicName, row.BuyingPrice, row.SellingPrice, row.Unit, row.IsWireBox,
+				row.PurchasePercentage, row.SellPercentage, row.CategoryID, row.BrandID, row.Barcodes, row.SKUs, row.Supplier,
+				row.Category, row.Brand, row.CreatedAt, row.UpdatedAt, row.DeletedAt,
+			})
+		})
+	})
+}
+
+func (s *Server) handleExportBills(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBillFilter(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.streamExport(w, r, "bills", billExportHeader, func(write func([]any) error) error {
+		return s.Store.ExportBills(r.Context(), filter, func(row store.BillExportRow) error {
+			return write([]any{
+				row.ID, invoice.Number(row.Bill), row.Customer, row.CustomerEmail, row.TotalAmount, row.LineCount,
+				row.CreatedAt, row.UpdatedAt,
+			})
+		})
+	})
+}
+
+func (s *Server) handleExportBillLines(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBillFilter(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.streamExport(w, r, "bill-lines", billLineExportHeader, func(write func([]any) error) error {
+		return s.Store.ExportBillLines(r.Context(), filter, func(row store.BillLineExportRow) error {
+			return write([]any{
+				row.BillID, invoice.Number(store.Bill{ID: row.BillID}), row.BillDate, row.Customer, row.ItemID, row.ItemName,
+				row.ArabicName, row.Unit, row.Quantity, row.UnitPrice, float64(row.Quantity) * row.UnitPrice,
+			})
+		})
+	})
+}
+
+// streamExport writes header and then whatever rows produce emits as an
+// attachment in the requested format (csv unless format=xlsx).
+func (s *Server) streamExport(w http.ResponseWriter, r *http.Request, name string, header []any, produce func(write func([]any) error) error) {
+	format := sheet.FormatCSV
+	if v := r.URL.Query().Get("format"); v != "" {
+		var err error
+		if format, err = sheet.DetectFormat(v, "", ""); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().UTC().Format("20060102"), format)
+	w.Header().Set("Content-Type", sheet.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+
+	out, err := sheet.NewWriter(w, format)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to export "+name)
+		return
+	}
+	err = out.WriteRow(header)
+	if err == nil {
+		err = produce(out.WriteRow)
+	}
+	if err != nil {
+		out.Abort()
+		if format == sheet.FormatXLSX {
+			// Nothing has been sent yet, so a proper error still fits
+			writeError(w, http.StatusInternalServerError, "failed to export "+name)
+			return
+		}
+		// CSV rows may already be on the wire; drop the connection rather
+		// than let the client keep a silently truncated file
+		panic(http.ErrAbortHandler)
+	}
+	if err := out.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,302 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"subahan-billing-backend/internal/sheet"
+	"subahan-billing-backend/internal/store"
+)
+
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 5000
+)
+
+// importColumns maps accepted header spellings (lowercased, without spaces,
+// underscores or dashes) to the item field they fill.
+var importColumns = map[string]string{
+	"itemid":             "itemId",
+	"id":                 "itemId",
+	"name":               "name",
+	"englishname":        "name",
+	"arabicname":         "arabicName",
+	"namear":             "arabicName",
+	"buyingprice":        "buyingPrice",
+	"baseprice":          "buyingPrice",
+	"sellingprice":       "sellingPrice",
+	"unit":               "unit",
+	"iswirebox":          "isWireBox",
+	"wirebox":            "isWireBox",
+	"purchasepercentage": "purchasePercentage",
+	"purchase%":          "purchasePercentage",
+	"sellpercentage":     "sellPercentage",
+	"sell%":              "sellPercentage",
+	"categoryid":         "categoryId",
+	"brandid":            "brandId",
+	"barcodes":           "barcodes",
+	"barcode":            "barcodes",
+	"skus":               "skus",
+	"sku":                "skus",
+	"supplier":           "supplier",
+}
+
+type importReport struct {
+	DryRun         bool                     `json:"dryRun"`
+	Applied        bool                     `json:"applied"`
+	Total          int                      `json:"total"`
+	Created        int                      `json:"created"`
+	Updated        int                      `json:"updated"`
+	Failed         int                      `json:"failed"`
+	IgnoredColumns []string                 `json:"ignoredColumns"`
+	Rows           []store.ItemImportResult `json:"rows"`
+}
+
+// handleImportItems accepts a CSV or XLSX catalog, either as the "file" field
+// of a multipart form or as the raw request body. Every row is a full item:
+// existing items are overwritten, new ones created. With dryRun=true nothing
+// is written; otherwise all rows are applied together or not at all.
+func (s *Server) handleImportItems(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	dryRun := strings.ToLower(r.URL.Query().Get("dryRun")) == "true"
+
+	var body io.Reader = r.Body
+	filename := ""
+	contentType := r.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		body = file
+		filename = header.Filename
+		contentType = header.Header.Get("Content-Type")
+		if strings.ToLower(r.FormValue("dryRun")) == "true" {
+			dryRun = true
+		}
+	}
+
+	format, err := sheet.DetectFormat(r.URL.Query().Get("format"), filename, contentType)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	records, err := sheet.ReadAll(body, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unable to read "+format+" file")
+		return
+	}
+	if len(records) < 2 {
+		writeError(w, http.StatusBadRequest, "file has no data rows")
+		return
+	}
+	if len(records)-1 > maxImportRows {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("file has more than %d rows", maxImportRows))
+		return
+	}
+
+	columns, ignored := mapImportColumns(records[0])
+	for _, required := range []string{"itemId", "name", "arabicName"} {
+		if _, ok := columns[required]; !ok {
+			writeError(w, http.StatusBadRequest, "missing column "+required)
+			return
+		}
+	}
+
+	report := importReport{DryRun: dryRun, IgnoredColumns: ignored}
+	invalid := map[int]store.ItemImportResult{}
+	valid := []store.ItemImportRow{}
+	seen := map[string]int{}
+	for i, record := range records[1:] {
+		line := i + 2
+		if blankRecord(record) {
+			continue
+		}
+		input, err := parseImportRecord(record, columns)
+		if err == nil {
+			// A row without an itemId creates an item numbered by the store
+			input.ItemID = strings.TrimSpace(input.ItemID)
+			if input.ItemID != "" {
+				var itemID string
+				if itemID, err = validateItemID(input.ItemID); err == nil {
+					input.ItemID = itemID
+				}
+			}
+		}
+		if err == nil {
+			err = validateItemInput(&input)
+		}
+		if err == nil {
+			if first, dup := seen[input.ItemID]; dup && input.ItemID != "" {
+				err = fmt.Errorf("itemId repeats row %d", first)
+			}
+		}
+		if err != nil {
+			invalid[line] = store.ItemImportResult{Row: line, ItemID: strings.TrimSpace(input.ItemID), Action: store.ImportActionError, Error: err.Error()}
+			continue
+		}
+		seen[input.ItemID] = line
+		valid = append(valid, store.ItemImportRow{Row: line, Item: input})
+	}
+
+	commit := !dryRun && len(invalid) == 0
+	results, ok, err := s.Store.ImportItems(r.Context(), valid, commit, currentUser(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to import items")
+		return
+	}
+
+	// Merge parse failures back in file order
+	report.Rows = make([]store.ItemImportResult, 0, len(results)+len(invalid))
+	next := 0
+	for line := 2; line <= len(records); line++ {
+		if result, bad := invalid[line]; bad {
+			report.Rows = append(report.Rows, result)
+		} else if next < len(results) && results[next].Row == line {
+			report.Rows = append(report.Rows, results[next])
+			next++
+		}
+	}
+	for _, row := range report.Rows {
+		switch row.Action {
+		case store.ImportActionCreate:
+			report.Created++
+		case store.ImportActionUpdate:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+	}
+	report.Total = len(report.Rows)
+	report.Applied = commit && ok
+
+	if report.Applied {
+		s.Cache.Invalidate("items:")
+	}
+	status := http.StatusOK
+	if !dryRun && !report.Applied {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, report)
+}
+
+func mapImportColumns(header []string) (map[string]int, []string) {
+	columns := map[string]int{}
+	ignored := []string{}
+	for i, name := range header {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		field, ok := importColumns[key]
+		if !ok {
+			if strings.TrimSpace(name) != "" {
+				ignored = append(ignored, name)
+			}
+			continue
+		}
+		if _, dup := columns[field]; !dup {
+			columns[field] = i
+		}
+	}
+	return columns, ignored
+}
+
+func parseImportRecord(record []string, columns map[string]int) (store.ItemCreate, error) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	optionalFloat := func(field string) (*float64, error) {
+		v := cell(field)
+		if v == "" {
+			return nil, nil
+		}
+		parsed, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", field)
+		}
+		return &parsed, nil
+	}
+	optionalString := func(field string) *string {
+		if v := cell(field); v != "" {
+			return &v
+		}
+		return nil
+	}
+
+	input := store.ItemCreate{
+		ItemID:     cell("itemId"),
+		Name:       cell("name"),
+		ArabicName: cell("arabicName"),
+		Unit:       cell("unit"),
+		CategoryID: optionalString("categoryId"),
+		BrandID:    optionalString("brandId"),
+	}
+
+	var err error
+	if input.BuyingPrice, err = optionalFloat("buyingPrice"); err != nil {
+		return input, err
+	}
+	selling, err := optionalFloat("sellingPrice")
+	if err != nil {
+		return input, err
+	}
+	if selling != nil {
+		input.SellingPrice = *selling
+	}
+	if input.PurchasePercentage, err = optionalFloat("purchasePercentage"); err != nil {
+		return input, err
+	}
+	if input.SellPercentage, err = optionalFloat("sellPercentage"); err != nil {
+		return input, err
+	}
+	switch strings.ToLower(cell("isWireBox")) {
+	case "", "false", "no", "n", "0":
+	case "true", "yes", "y", "1":
+		input.IsWireBox = true
+	default:
+		return input, errors.New("isWireBox must be true or false")
+	}
+
+	// Codes are only touched when the file has a code column
+	_, hasBarcodes := columns["barcodes"]
+	_, hasSKUs := columns["skus"]
+	if hasBarcodes || hasSKUs {
+		input.Codes = []store.ItemCode{}
+		for _, code := range splitList(cell("barcodes")) {
+			input.Codes = append(input.Codes, store.ItemCode{Code: code})
+		}
+		supplier := optionalString("supplier")
+		for _, code := range splitList(cell("skus")) {
+			input.Codes = append(input.Codes, store.ItemCode{Code: code, Kind: store.CodeKindSKU, Supplier: supplier})
+		}
+	}
+	return input, nil
+}
+
+func splitList(v string) []string {
+	parts := strings.FieldsFunc(v, func(r rune) bool { return r == ';' || r == '|' || r == '\n' })
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func blankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,13 +11,14 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"subahan-billing-backend/internal/pricing"
 	"subahan-billing-backend/internal/store"
 )
 
 const cacheTTL = time.Hour
 
 func (s *Server) handleListItems(w http.ResponseWriter, r *http.Request) {
-	includeDeleted := strings.ToLower(r.URL.Query().Get("includeDeleted")) == "true"
+	filter := parseItemFilter(r)
 	limit := 100
 	offset := 0
 
@@ -31,25 +33,40 @@ func (s *Server) handleListItems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Search results are ranked, so they bypass the cache and pagination
+	if filter.Query != "" {
+		if r.URL.Query().Get("limit") == "" {
+			limit = 25
+		}
+		items, err := s.Store.SearchItems(r.Context(), filter, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to search items")
+			return
+		}
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+
 	cacheKey := "items:active"
-	if includeDeleted {
+	if filter.IncludeDeleted {
 		cacheKey = "items:all"
 	}
-	// Skip cache for paginated requests
-	if offset == 0 && limit >= 100 {
+	// Skip cache for paginated or filtered requests
+	cacheable := offset == 0 && limit >= 100 && filter.CategoryID == "" && filter.BrandID == ""
+	if cacheable {
 		if cached, ok := s.Cache.Get(cacheKey); ok {
 			writeJSON(w, http.StatusOK, cached)
 			return
 		}
 	}
 
-	items, err := s.Store.ListItems(r.Context(), includeDeleted, limit, offset)
+	items, err := s.Store.ListItems(r.Context(), filter, limit, offset)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to load items")
 		return
 	}
 
-	if offset == 0 && limit >= 100 {
+	if cacheable {
 		s.Cache.Set(cacheKey, items, cacheTTL)
 	}
 	writeJSON(w, http.StatusOK, items)
@@ -61,63 +78,37 @@ func (s *Server) handleCreateItem(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid payload")
 		return
 	}
-	normalizedID, err := validateItemID(input.ItemID)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
-		return
-	}
-	input.ItemID = normalizedID
-	if strings.TrimSpace(input.Name) == "" {
-		writeError(w, http.StatusBadRequest, "name is required")
-		return
+	// Without an itemId the store numbers the item from its prefix
+	if strings.TrimSpace(input.ItemID) != "" {
+		normalizedID, err := validateItemID(input.ItemID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		input.ItemID = normalizedID
+	} else {
+		input.ItemID = ""
 	}
-	if strings.TrimSpace(input.ArabicName) == "" {
-		writeError(w, http.StatusBadRequest, "arabicName is required")
+	if err := validateItemInput(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Validate based on Wire/Box mode
-	if input.IsWireBox {
-		// Wire/Box mode: base purchase price and percentages are required
-		if input.BuyingPrice == nil || *input.BuyingPrice <= 0 {
-			writeError(w, http.StatusBadRequest, "buyingPrice (base purchase price) is required for Wire/Box items")
-			return
-		}
-		if input.PurchasePercentage == nil || *input.PurchasePercentage < 0 || *input.PurchasePercentage > 100 {
-			writeError(w, http.StatusBadRequest, "purchasePercentage must be between 0 and 100")
+	item, err := s.Store.CreateItem(r.Context(), input)
+	if err != nil {
+		if err == store.ErrInvalidReference {
+			writeError(w, http.StatusBadRequest, "categoryId, brandId or unit not found")
 			return
 		}
-		if input.SellPercentage == nil || *input.SellPercentage < 0 || *input.SellPercentage > 100 {
-			writeError(w, http.StatusBadRequest, "sellPercentage must be between 0 and 100")
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "barcode or SKU is already assigned to another item")
 			return
 		}
-		// Calculate selling price using discount percentages from base price
-		// buyingPrice = base/reference price (e.g., 1.000 KWD)
-		// Selling price = base × (1 - sell%) → e.g., 1.000 × (1 - 0.08) = 0.920 KWD
-		// Actual purchase cost = base × (1 - purchase%) → e.g., 1.000 × (1 - 0.09) = 0.910 KWD
-		// Profit = 0.920 - 0.910 = 0.010 KWD (1% of base)
-		input.SellingPrice = *input.BuyingPrice * (1 - (*input.SellPercentage / 100))
-	} else {
-		// Normal mode: both prices are required
-		if input.BuyingPrice == nil || *input.BuyingPrice <= 0 {
-			writeError(w, http.StatusBadRequest, "buyingPrice is required and must be positive")
+		var invalidErr *store.InvalidError
+		if errors.As(err, &invalidErr) {
+			writeError(w, http.StatusConflict, err.Error())
 			return
 		}
-		if input.SellingPrice <= 0 {
-			writeError(w, http.StatusBadRequest, "sellingPrice must be positive")
-			return
-		}
-		// Clear percentage fields for normal items
-		input.PurchasePercentage = nil
-		input.SellPercentage = nil
-	}
-
-	if strings.TrimSpace(input.Unit) == "" {
-		input.Unit = "pcs"
-	}
-
-	item, err := s.Store.CreateItem(r.Context(), input)
-	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create item")
 		return
 	}
@@ -134,57 +125,110 @@ func (s *Server) handleUpdateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	input.ItemID = itemID
-	if strings.TrimSpace(input.Name) == "" {
-		writeError(w, http.StatusBadRequest, "name is required")
-		return
-	}
-	if strings.TrimSpace(input.ArabicName) == "" {
-		writeError(w, http.StatusBadRequest, "arabicName is required")
+	if err := validateItemInput(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Validate based on Wire/Box mode
-	if input.IsWireBox {
-		// Wire/Box mode: base purchase price and percentages are required
-		if input.BuyingPrice == nil || *input.BuyingPrice <= 0 {
-			writeError(w, http.StatusBadRequest, "buyingPrice (base purchase price) is required for Wire/Box items")
+	item, err := s.Store.UpdateItem(r.Context(), input, currentUser(r))
+	if err != nil {
+		if err == store.ErrInvalidReference {
+			writeError(w, http.StatusBadRequest, "categoryId, brandId or unit not found")
 			return
 		}
-		if input.PurchasePercentage == nil || *input.PurchasePercentage < 0 || *input.PurchasePercentage > 100 {
-			writeError(w, http.StatusBadRequest, "purchasePercentage must be between 0 and 100")
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "barcode or SKU is already assigned to another item")
 			return
 		}
-		if input.SellPercentage == nil || *input.SellPercentage < 0 || *input.SellPercentage > 100 {
-			writeError(w, http.StatusBadRequest, "sellPercentage must be between 0 and 100")
+		writeError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (s *Server) handleItemPriceHistory(w http.ResponseWriter, r *http.Request) {
+	itemID := chi.URLParam(r, "itemId")
+	changes, err := s.Store.ListPriceChanges(r.Context(), itemID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
 			return
 		}
-		// Calculate selling price using discount percentages from base price
-		// buyingPrice = base/reference price (e.g., 1.000 KWD)
-		// Selling price = base × (1 - sell%) → e.g., 1.000 × (1 - 0.08) = 0.920 KWD
-		// Actual purchase cost = base × (1 - purchase%) → e.g., 1.000 × (1 - 0.09) = 0.910 KWD
-		// Profit = 0.920 - 0.910 = 0.010 KWD (1% of base)
-		input.SellingPrice = *input.BuyingPrice * (1 - (*input.SellPercentage / 100))
-	} else {
-		// Normal mode: both prices are required
-		if input.BuyingPrice == nil || *input.BuyingPrice <= 0 {
-			writeError(w, http.StatusBadRequest, "buyingPrice is required and must be positive")
-			return
+		writeError(w, http.StatusInternalServerError, "failed to load price history")
+		return
+	}
+	writeJSON(w, http.StatusOK, changes)
+}
+
+// handleQuoteItemPrice shows what a bill line would cost for a customer or
+// price list, in the item's base unit or the given unit. A quantity shows
+// which quantity break would apply.
+func (s *Server) handleQuoteItemPrice(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	optional := func(key string) *string {
+		if v := strings.TrimSpace(q.Get(key)); v != "" {
+			return &v
 		}
-		if input.SellingPrice <= 0 {
-			writeError(w, http.StatusBadRequest, "sellingPrice must be positive")
+		return nil
+	}
+
+	quantity := 1
+	if v := strings.TrimSpace(q.Get("quantity")); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be a positive integer")
 			return
 		}
-		// Clear percentage fields for normal items
-		input.PurchasePercentage = nil
-		input.SellPercentage = nil
+		quantity = parsed
 	}
 
-	item, err := s.Store.UpdateItem(r.Context(), input)
+	quote, err := s.Store.QuoteItemPrice(r.Context(), chi.URLParam(r, "itemId"), strings.TrimSpace(q.Get("unit")), quantity, optional("customerId"), optional("priceListId"))
 	if err != nil {
-		writeError(w, http.StatusNotFound, "item not found")
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"itemId":     quote.ItemID,
+		"unit":       quote.Unit,
+		"unitFactor": quote.UnitFactor,
+		"unitPrice":  quote.UnitPrice,
+		"pricing":    quote.Pricing,
+		"promotion":  quote.PromotionName,
+	})
+}
+
+type minMarginRequest struct {
+	MinMarginPercent *float64 `json:"minMarginPercent"`
+}
+
+// handleSetItemMinMargin sets the margin a hand-typed bill price must keep
+// on the item; null falls back to its category's.
+func (s *Server) handleSetItemMinMargin(w http.ResponseWriter, r *http.Request) {
+	var req minMarginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if m := req.MinMarginPercent; m != nil && (*m < 0 || *m >= 100) {
+		writeError(w, http.StatusBadRequest, "minMarginPercent must be at least 0 and below 100")
 		return
 	}
 
+	item, err := s.Store.SetItemMinMargin(r.Context(), chi.URLParam(r, "itemId"), req.MinMarginPercent)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to save minimum margin")
+		return
+	}
 	s.Cache.Invalidate("items:")
 	writeJSON(w, http.StatusOK, item)
 }
@@ -199,6 +243,20 @@ func (s *Server) handleGetItem(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, item)
 }
 
+func (s *Server) handleGetItemByCode(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimSpace(chi.URLParam(r, "code"))
+	item, err := s.Store.GetItemByCode(r.Context(), code)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "no item matches this code")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to look up code")
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
 func (s *Server) handleDeleteItem(w http.ResponseWriter, r *http.Request) {
 	itemID := chi.URLParam(r, "itemId")
 	if err := s.Store.SoftDeleteItem(r.Context(), itemID); err != nil {
@@ -210,9 +268,59 @@ func (s *Server) handleDeleteItem(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
+type mergeItemsRequest struct {
+	SourceItemIDs []string `json:"sourceItemIds"`
+}
+
+// handleMergeItems folds duplicate items into the item in the URL. The
+// sources disappear from the catalog but their IDs keep resolving.
+func (s *Server) handleMergeItems(w http.ResponseWriter, r *http.Request) {
+	var req mergeItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	sources := []string{}
+	seen := map[string]bool{}
+	for _, id := range req.SourceItemIDs {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+	if len(sources) == 0 {
+		writeError(w, http.StatusBadRequest, "sourceItemIds is required")
+		return
+	}
+
+	merge, err := s.Store.MergeItems(r.Context(), chi.URLParam(r, "itemId"), sources, currentUser(r))
+	if err != nil {
+		writeStoreError(w, err, "item not found", "failed to merge items")
+		return
+	}
+
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, merge)
+}
+
+// handleListTrash lists deleted items that can still be restored, with when
+// the cleanup will purge them, or archive them if bills or stock name them.
+func (s *Server) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	trash, err := s.Store.ListTrash(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load trash")
+		return
+	}
+	writeJSON(w, http.StatusOK, trash)
+}
+
 func (s *Server) handleRestoreItem(w http.ResponseWriter, r *http.Request) {
 	itemID := chi.URLParam(r, "itemId")
 	if err := s.Store.RestoreItem(r.Context(), itemID); err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "a barcode or SKU of this item now belongs to another item")
+			return
+		}
 		writeError(w, http.StatusConflict, "restore window expired or item not found")
 		return
 	}
@@ -221,6 +329,100 @@ func (s *Server) handleRestoreItem(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "restored"})
 }
 
+// validateItemInput applies the catalog rules shared by item create, update
+// and import. It normalizes codes in place and derives the selling price of
+// Wire/Box items.
+func validateItemInput(input *store.ItemCreate) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("name is required")
+	}
+	if strings.TrimSpace(input.ArabicName) == "" {
+		return errors.New("arabicName is required")
+	}
+	for _, field := range []**string{&input.CategoryID, &input.BrandID} {
+		if *field != nil {
+			v := strings.TrimSpace(**field)
+			*field = &v
+		}
+	}
+	codes, err := normalizeItemCodes(input.Codes)
+	if err != nil {
+		return err
+	}
+	input.Codes = codes
+	if err := normalizeItemUnits(input); err != nil {
+		return err
+	}
+
+	// Validate based on Wire/Box mode
+	if input.IsWireBox {
+		// Wire/Box mode: base purchase price and percentages are required
+		if input.BuyingPrice == nil || *input.BuyingPrice <= 0 {
+			return errors.New("buyingPrice (base purchase price) is required for Wire/Box items")
+		}
+		if input.PurchasePercentage == nil || *input.PurchasePercentage < 0 || *input.PurchasePercentage > 100 {
+			return errors.New("purchasePercentage must be between 0 and 100")
+		}
+		if input.SellPercentage == nil || *input.SellPercentage < 0 || *input.SellPercentage > 100 {
+			return errors.New("sellPercentage must be between 0 and 100")
+		}
+		// Calculate selling price using discount percentages from base price
+		// buyingPrice = base/reference price (e.g., 1.000 KWD)
+		// Selling price = base × (1 - sell%) → e.g., 1.000 × (1 - 0.08) = 0.920 KWD
+		// Actual purchase cost = base × (1 - purchase%) → e.g., 1.000 × (1 - 0.09) = 0.910 KWD
+		// Profit = 0.920 - 0.910 = 0.010 KWD (1% of base)
+		input.SellingPrice = pricing.WireBoxSellingPrice(*input.BuyingPrice, *input.SellPercentage)
+	} else {
+		// Normal mode: both prices are required
+		if input.BuyingPrice == nil || *input.BuyingPrice <= 0 {
+			return errors.New("buyingPrice is required and must be positive")
+		}
+		if input.SellingPrice <= 0 {
+			return errors.New("sellingPrice must be positive")
+		}
+		// Clear percentage fields for normal items
+		input.PurchasePercentage = nil
+		input.SellPercentage = nil
+	}
+	return nil
+}
+
+// normalizeItemUnits defaults the base unit to pcs and checks the extra sale
+// units against it.
+func normalizeItemUnits(input *store.ItemCreate) error {
+	input.Unit = strings.TrimSpace(input.Unit)
+	if input.Unit == "" {
+		input.Unit = "pcs"
+	}
+	seen := map[string]bool{input.Unit: true}
+	for i := range input.Units {
+		u := &input.Units[i]
+		u.Unit = strings.TrimSpace(u.Unit)
+		if u.Unit == "" {
+			return errors.New("units: unit is required")
+		}
+		if seen[u.Unit] {
+			return fmt.Errorf("units: %s is listed twice or is the base unit", u.Unit)
+		}
+		seen[u.Unit] = true
+		if u.Factor <= 0 {
+			return fmt.Errorf("units: factor for %s must be positive", u.Unit)
+		}
+	}
+	return nil
+}
+
+// parseItemFilter reads the filters shared by the item list endpoints.
+func parseItemFilter(r *http.Request) store.ItemFilter {
+	q := r.URL.Query()
+	return store.ItemFilter{
+		Query:          strings.TrimSpace(q.Get("q")),
+		IncludeDeleted: strings.ToLower(q.Get("includeDeleted")) == "true",
+		CategoryID:     strings.TrimSpace(q.Get("categoryId")),
+		BrandID:        strings.TrimSpace(q.Get("brandId")),
+	}
+}
+
 func validateItemID(itemID string) (string, error) {
 	trimmed := strings.TrimSpace(itemID)
 	if trimmed == "" {
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"subahan-billing-backend/internal/cache"
+	"subahan-billing-backend/internal/config"
+	"subahan-billing-backend/internal/migrations"
+	"subahan-billing-backend/internal/store"
+)
+
+// testServer connects to the database in TEST_DATABASE_URL, migrated to the
+// latest schema, and skips the test when it is not set.
+func testServer(t *testing.T, opts store.Options) *Server {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := migrations.Run(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewServer(&config.Config{}, store.New(pool, opts), cache.New(), nil, nil)
+}
+
+// An item posted without an itemId is numbered from the configured prefix.
+func TestCreateItemWithoutID(t *testing.T) {
+	b := make([]byte, 6)
+	rand.Read(b)
+	prefix := "H" + hex.EncodeToString(b)
+	s := testServer(t, store.Options{ItemIDPrefix: prefix, ItemIDWidth: 4})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader(`{"name": "Cable", "arabicName": "كيبل", "sellingPrice": 1}`))
+	rec := httptest.NewRecorder()
+	s.handleCreateItem(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var item store.Item
+	if err := json.NewDecoder(rec.Body).Decode(&item); err != nil {
+		t.Fatal(err)
+	}
+	if want := prefix + "0001"; item.ItemID != want {
+		t.Errorf("itemId = %s, want %s", item.ItemID, want)
+	}
+}
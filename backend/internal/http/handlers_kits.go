@@ -0,0 +1,59 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleGetKitComponents(w http.ResponseWriter, r *http.Request) {
+	components, err := s.Store.GetKitComponents(r.Context(), chi.URLParam(r, "itemId"))
+	if err != nil {
+		writeKitError(w, err, "failed to load kit components")
+		return
+	}
+	writeJSON(w, http.StatusOK, components)
+}
+
+type kitComponentsRequest struct {
+	Components []store.KitComponent `json:"components"`
+}
+
+// handleSetKitComponents replaces an item's components; an empty list turns
+// a kit back into a plain item.
+func (s *Server) handleSetKitComponents(w http.ResponseWriter, r *http.Request) {
+	var req kitComponentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	for i := range req.Components {
+		c := &req.Components[i]
+		c.ItemID = strings.TrimSpace(c.ItemID)
+		c.Unit = strings.TrimSpace(c.Unit)
+		if c.ItemID == "" {
+			writeError(w, http.StatusBadRequest, "itemId is required for every component")
+			return
+		}
+		if c.Quantity <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be a positive whole number")
+			return
+		}
+	}
+
+	components, err := s.Store.SetKitComponents(r.Context(), chi.URLParam(r, "itemId"), req.Components)
+	if err != nil {
+		writeKitError(w, err, "failed to save kit components")
+		return
+	}
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, components)
+}
+
+func writeKitError(w http.ResponseWriter, err error, fallback string) {
+	writeStoreError(w, err, "item not found", fallback)
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/media"
+	"subahan-billing-backend/internal/store"
+)
+
+const maxImageBytes = 10 << 20
+
+func (s *Server) handleListItemImages(w http.ResponseWriter, r *http.Request) {
+	images, err := s.Store.ListItemImages(r.Context(), chi.URLParam(r, "itemId"))
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load images")
+		return
+	}
+	writeJSON(w, http.StatusOK, images)
+}
+
+// handleUploadItemImage accepts a JPEG, PNG or GIF as the "file" field of a
+// multipart form or as the raw request body. The original is kept as sent
+// and a JPEG thumbnail is made alongside it.
+func (s *Server) handleUploadItemImage(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageBytes)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("image must be at most %d MB", maxImageBytes>>20))
+		return
+	}
+	decoded, err := media.Decode(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	thumb, err := media.Thumbnail(decoded.Image, media.ThumbnailSize)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to make thumbnail")
+		return
+	}
+
+	image, err := s.Store.CreateItemImage(r.Context(), store.ItemImage{
+		ItemID:      chi.URLParam(r, "itemId"),
+		ContentType: decoded.ContentType,
+		Width:       decoded.Width,
+		Height:      decoded.Height,
+		Size:        int64(len(data)),
+	}, currentUser(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to save image")
+		return
+	}
+
+	err = s.Media.Put(r.Context(), media.OriginalKey(image.ID), data)
+	if err == nil {
+		err = s.Media.Put(r.Context(), media.ThumbnailKey(image.ID), thumb)
+	}
+	if err != nil {
+		// Leave it to the cleanup job to remove whatever was written
+		_ = s.Store.DeleteItemImage(r.Context(), image.ItemID, image.ID)
+		writeError(w, http.StatusInternalServerError, "failed to store image")
+		return
+	}
+
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusCreated, image)
+}
+
+func (s *Server) handleDeleteItemImage(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteItemImage(r.Context(), chi.URLParam(r, "itemId"), chi.URLParam(r, "imageId")); err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "image not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to delete image")
+		return
+	}
+	s.Cache.Invalidate("items:")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleItemMedia serves an item image, or its thumbnail with size=thumb.
+// It needs no login so the files can be used directly in <img> tags; image
+// IDs are random and an image's files never change, so clients and proxies
+// may cache them for good.
+func (s *Server) handleItemMedia(w http.ResponseWriter, r *http.Request) {
+	image, err := s.Store.GetItemImage(r.Context(), chi.URLParam(r, "itemId"), chi.URLParam(r, "imageId"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	key, contentType := media.OriginalKey(image.ID), image.ContentType
+	switch r.URL.Query().Get("size") {
+	case "", "original":
+	case "thumb":
+		key, contentType = media.ThumbnailKey(image.ID), "image/jpeg"
+	default:
+		writeError(w, http.StatusBadRequest, "size must be original or thumb")
+		return
+	}
+
+	file, modTime, err := s.Media.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, media.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "image not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to read image")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+key+`"`)
+	http.ServeContent(w, r, "", modTime, file)
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListPriceIndexes(w http.ResponseWriter, r *http.Request) {
+	indexes, err := s.Store.ListPriceIndexes(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load price indexes")
+		return
+	}
+	writeJSON(w, http.StatusOK, indexes)
+}
+
+func (s *Server) handleCreatePriceIndex(w http.ResponseWriter, r *http.Request) {
+	var input store.PriceIndexInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	index, err := s.Store.CreatePriceIndex(r.Context(), input)
+	if err != nil {
+		writePriceIndexError(w, err, "failed to create price index")
+		return
+	}
+	writeJSON(w, http.StatusCreated, index)
+}
+
+func (s *Server) handleUpdatePriceIndex(w http.ResponseWriter, r *http.Request) {
+	var input store.PriceIndexInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	index, err := s.Store.UpdatePriceIndex(r.Context(), chi.URLParam(r, "indexId"), input)
+	if err != nil {
+		writePriceIndexError(w, err, "failed to update price index")
+		return
+	}
+	writeJSON(w, http.StatusOK, index)
+}
+
+func (s *Server) handleDeletePriceIndex(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeletePriceIndex(r.Context(), chi.URLParam(r, "indexId")); err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "items are linked to this price index")
+			return
+		}
+		writeStoreError(w, err, "price index not found", "failed to delete price index")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (s *Server) handleListPriceIndexValues(w http.ResponseWriter, r *http.Request) {
+	values, err := s.Store.ListPriceIndexValues(r.Context(), chi.URLParam(r, "indexId"))
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "price index not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load price index values")
+		return
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+type priceIndexValueRequest struct {
+	// EffectiveDate is YYYY-MM-DD; empty means today
+	EffectiveDate string  `json:"effectiveDate"`
+	Value         float64 `json:"value"`
+}
+
+// handlePostPriceIndexValue records an index value. When it is the latest,
+// linked Wire/Box items are repriced, so the item cache is dropped.
+func (s *Server) handlePostPriceIndexValue(w http.ResponseWriter, r *http.Request) {
+	var req priceIndexValueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if req.Value <= 0 {
+		writeError(w, http.StatusBadRequest, "value must be positive")
+		return
+	}
+	req.EffectiveDate = strings.TrimSpace(req.EffectiveDate)
+	if req.EffectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", req.EffectiveDate); err != nil {
+			writeError(w, http.StatusBadRequest, "effectiveDate must be a date (YYYY-MM-DD)")
+			return
+		}
+	}
+
+	result, err := s.Store.PostPriceIndexValue(r.Context(), chi.URLParam(r, "indexId"), req.EffectiveDate, req.Value, currentUser(r))
+	if err != nil {
+		writePriceIndexError(w, err, "failed to post price index value")
+		return
+	}
+	if result.Applied {
+		s.Cache.Invalidate("items:")
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+func (s *Server) handleLinkItemPriceIndex(w http.ResponseWriter, r *http.Request) {
+	var link store.PriceIndexLink
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if link.PriceIndexID != nil && strings.TrimSpace(*link.PriceIndexID) == "" {
+		link.PriceIndexID = nil
+	}
+	if link.Factor != nil && *link.Factor <= 0 {
+		writeError(w, http.StatusBadRequest, "factor must be positive")
+		return
+	}
+
+	item, err := s.Store.LinkItemPriceIndex(r.Context(), chi.URLParam(r, "itemId"), link, currentUser(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writePriceIndexError(w, err, "failed to link price index")
+		return
+	}
+	s.Cache.Invalidate("items:")
+	writeJSON(w, http.StatusOK, item)
+}
+
+func writePriceIndexError(w http.ResponseWriter, err error, fallback string) {
+	if err == store.ErrConflict {
+		writeError(w, http.StatusConflict, "a price index with this name already exists")
+		return
+	}
+	writeStoreError(w, err, "price index not found", fallback)
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListPriceLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := s.Store.ListPriceLists(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load price lists")
+		return
+	}
+	writeJSON(w, http.StatusOK, lists)
+}
+
+func (s *Server) handleCreatePriceList(w http.ResponseWriter, r *http.Request) {
+	var input store.PriceListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validatePriceList(input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	list, err := s.Store.CreatePriceList(r.Context(), input)
+	if err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "price list already exists")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create price list")
+		return
+	}
+	writeJSON(w, http.StatusCreated, list)
+}
+
+func (s *Server) handleUpdatePriceList(w http.ResponseWriter, r *http.Request) {
+	listID := chi.URLParam(r, "priceListId")
+	var input store.PriceListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validatePriceList(input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	list, err := s.Store.UpdatePriceList(r.Context(), listID, input)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			writeError(w, http.StatusNotFound, "price list not found")
+		case store.ErrConflict:
+			writeError(w, http.StatusConflict, "price list already exists")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to update price list")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleDeletePriceList(w http.ResponseWriter, r *http.Request) {
+	listID := chi.URLParam(r, "priceListId")
+	if err := s.Store.DeletePriceList(r.Context(), listID); err != nil {
+		writeError(w, http.StatusNotFound, "price list not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func validatePriceList(input store.PriceListInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "name is required"
+	}
+	if input.AdjustmentPercent <= -100 || input.AdjustmentPercent > 1000 {
+		return "adjustmentPercent must be above -100 and at most 1000"
+	}
+	if input.SellPercentage != nil && (*input.SellPercentage < 0 || *input.SellPercentage > 100) {
+		return "sellPercentage must be between 0 and 100"
+	}
+	return ""
+}
+
+func (s *Server) handleListPriceListItems(w http.ResponseWriter, r *http.Request) {
+	listID := chi.URLParam(r, "priceListId")
+	entries, err := s.Store.ListPriceListItems(r.Context(), listID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "price list not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load price list items")
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleSetPriceListItem sets one item's price on a list: either a fixed
+// price per base unit or, for Wire/Box items, a sell percentage.
+func (s *Server) handleSetPriceListItem(w http.ResponseWriter, r *http.Request) {
+	listID := chi.URLParam(r, "priceListId")
+	var entry store.PriceListItem
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	entry.ItemID = chi.URLParam(r, "itemId")
+	if entry.Price == nil && entry.SellPercentage == nil {
+		writeError(w, http.StatusBadRequest, "price or sellPercentage is required")
+		return
+	}
+	if entry.Price != nil && *entry.Price <= 0 {
+		writeError(w, http.StatusBadRequest, "price must be positive")
+		return
+	}
+	if entry.SellPercentage != nil && (*entry.SellPercentage < 0 || *entry.SellPercentage > 100) {
+		writeError(w, http.StatusBadRequest, "sellPercentage must be between 0 and 100")
+		return
+	}
+
+	entry, err := s.Store.SetPriceListItem(r.Context(), listID, entry)
+	if err != nil {
+		if err == store.ErrInvalidReference {
+			writeError(w, http.StatusNotFound, "price list or item not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to save price list item")
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) handleDeletePriceListItem(w http.ResponseWriter, r *http.Request) {
+	listID := chi.URLParam(r, "priceListId")
+	itemID := chi.URLParam(r, "itemId")
+	if err := s.Store.DeletePriceListItem(r.Context(), listID, itemID); err != nil {
+		writeError(w, http.StatusNotFound, "price list item not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
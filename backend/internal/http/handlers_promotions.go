@@ -0,0 +1,224 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func pricingRuleFilter(r *http.Request) store.PricingRuleFilter {
+	q := r.URL.Query()
+	return store.PricingRuleFilter{
+		ItemID:     strings.TrimSpace(q.Get("itemId")),
+		CategoryID: strings.TrimSpace(q.Get("categoryId")),
+		Running:    q.Get("running") == "true",
+	}
+}
+
+func (s *Server) handleListQuantityBreaks(w http.ResponseWriter, r *http.Request) {
+	breaks, err := s.Store.ListQuantityBreaks(r.Context(), pricingRuleFilter(r))
+	if err != nil {
+		writePricingRuleError(w, err, "quantity break", "failed to load quantity breaks")
+		return
+	}
+	writeJSON(w, http.StatusOK, breaks)
+}
+
+func (s *Server) handleCreateQuantityBreak(w http.ResponseWriter, r *http.Request) {
+	var input store.QuantityBreakInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateQuantityBreak(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	b, err := s.Store.CreateQuantityBreak(r.Context(), input)
+	if err != nil {
+		writePricingRuleError(w, err, "quantity break", "failed to create quantity break")
+		return
+	}
+	writeJSON(w, http.StatusCreated, b)
+}
+
+func (s *Server) handleUpdateQuantityBreak(w http.ResponseWriter, r *http.Request) {
+	var input store.QuantityBreakInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateQuantityBreak(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	b, err := s.Store.UpdateQuantityBreak(r.Context(), chi.URLParam(r, "breakId"), input)
+	if err != nil {
+		writePricingRuleError(w, err, "quantity break", "failed to update quantity break")
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func (s *Server) handleDeleteQuantityBreak(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteQuantityBreak(r.Context(), chi.URLParam(r, "breakId")); err != nil {
+		writePricingRuleError(w, err, "quantity break", "failed to delete quantity break")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func validateQuantityBreak(input *store.QuantityBreakInput) string {
+	for _, field := range []**string{&input.ItemID, &input.CategoryID} {
+		if *field != nil {
+			if v := strings.TrimSpace(**field); v == "" {
+				*field = nil
+			} else {
+				*field = &v
+			}
+		}
+	}
+	if msg := validatePricingRuleValue(input.ItemID, input.CategoryID, input.Price, input.DiscountPercent); msg != "" {
+		return msg
+	}
+	if input.MinQuantity <= 0 {
+		return "minQuantity must be positive"
+	}
+	return ""
+}
+
+func (s *Server) handleListPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := s.Store.ListPromotions(r.Context(), pricingRuleFilter(r))
+	if err != nil {
+		writePricingRuleError(w, err, "promotion", "failed to load promotions")
+		return
+	}
+	writeJSON(w, http.StatusOK, promotions)
+}
+
+func (s *Server) handleGetPromotion(w http.ResponseWriter, r *http.Request) {
+	promotion, err := s.Store.GetPromotion(r.Context(), chi.URLParam(r, "promotionId"))
+	if err != nil {
+		writePricingRuleError(w, err, "promotion", "failed to load promotion")
+		return
+	}
+	writeJSON(w, http.StatusOK, promotion)
+}
+
+func (s *Server) handleCreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var input store.PromotionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validatePromotion(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	promotion, err := s.Store.CreatePromotion(r.Context(), input)
+	if err != nil {
+		writePricingRuleError(w, err, "promotion", "failed to create promotion")
+		return
+	}
+	writeJSON(w, http.StatusCreated, promotion)
+}
+
+func (s *Server) handleUpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	var input store.PromotionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validatePromotion(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	promotion, err := s.Store.UpdatePromotion(r.Context(), chi.URLParam(r, "promotionId"), input)
+	if err != nil {
+		writePricingRuleError(w, err, "promotion", "failed to update promotion")
+		return
+	}
+	writeJSON(w, http.StatusOK, promotion)
+}
+
+func (s *Server) handleDeletePromotion(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeletePromotion(r.Context(), chi.URLParam(r, "promotionId")); err != nil {
+		writePricingRuleError(w, err, "promotion", "failed to delete promotion")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func validatePromotion(input *store.PromotionInput) string {
+	for _, field := range []**string{&input.ItemID, &input.CategoryID} {
+		if *field != nil {
+			if v := strings.TrimSpace(**field); v == "" {
+				*field = nil
+			} else {
+				*field = &v
+			}
+		}
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return "name is required"
+	}
+	if msg := validatePricingRuleValue(input.ItemID, input.CategoryID, input.Price, input.DiscountPercent); msg != "" {
+		return msg
+	}
+	input.StartsOn = strings.TrimSpace(input.StartsOn)
+	input.EndsOn = strings.TrimSpace(input.EndsOn)
+	startsOn, err := time.Parse("2006-01-02", input.StartsOn)
+	if err != nil {
+		return "startsOn must be a date (YYYY-MM-DD)"
+	}
+	endsOn, err := time.Parse("2006-01-02", input.EndsOn)
+	if err != nil {
+		return "endsOn must be a date (YYYY-MM-DD)"
+	}
+	if endsOn.Before(startsOn) {
+		return "endsOn must not be before startsOn"
+	}
+	return ""
+}
+
+// validatePricingRuleValue checks what breaks and promotions share: one
+// item or one category, and a fixed price or a discount. A category covers
+// items at different prices, so it only takes a discount.
+func validatePricingRuleValue(itemID, categoryID *string, price, discountPercent *float64) string {
+	if (itemID == nil) == (categoryID == nil) {
+		return "one of itemId or categoryId is required"
+	}
+	if (price == nil) == (discountPercent == nil) {
+		return "one of price or discountPercent is required"
+	}
+	if categoryID != nil && price != nil {
+		return "a category takes discountPercent, not price"
+	}
+	if price != nil && *price <= 0 {
+		return "price must be positive"
+	}
+	if discountPercent != nil && (*discountPercent <= 0 || *discountPercent >= 100) {
+		return "discountPercent must be above 0 and below 100"
+	}
+	return ""
+}
+
+func writePricingRuleError(w http.ResponseWriter, err error, what, fallback string) {
+	switch err {
+	case store.ErrInvalidReference:
+		writeError(w, http.StatusBadRequest, "item or category not found")
+	case store.ErrConflict:
+		writeError(w, http.StatusConflict, "the item or category already has a break at this minQuantity")
+	default:
+		writeStoreError(w, err, what+" not found", fallback)
+	}
+}
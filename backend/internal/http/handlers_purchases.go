@@ -0,0 +1,177 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListPurchases(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	offset := 0
+
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	period, err := parseReportRange(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := store.PurchaseFilter{
+		SupplierID: strings.TrimSpace(r.URL.Query().Get("supplierId")),
+		Status:     strings.TrimSpace(r.URL.Query().Get("status")),
+		From:       period.From,
+		To:         period.To,
+	}
+	if filter.Status != "" && filter.Status != store.PurchaseDraft && filter.Status != store.PurchasePosted {
+		writeError(w, http.StatusBadRequest, "status must be draft or posted")
+		return
+	}
+
+	purchases, err := s.Store.ListPurchases(r.Context(), filter, limit, offset)
+	if err != nil {
+		writePurchaseError(w, err, "failed to list purchases")
+		return
+	}
+	writeJSON(w, http.StatusOK, purchases)
+}
+
+func (s *Server) handleGetPurchase(w http.ResponseWriter, r *http.Request) {
+	purchase, err := s.Store.GetPurchase(r.Context(), chi.URLParam(r, "purchaseId"))
+	if err != nil {
+		writePurchaseError(w, err, "failed to load purchase")
+		return
+	}
+	writeJSON(w, http.StatusOK, purchase)
+}
+
+func (s *Server) handleCreatePurchase(w http.ResponseWriter, r *http.Request) {
+	var input store.PurchaseInvoiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if err := validatePurchase(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	purchase, err := s.Store.CreatePurchase(r.Context(), input, currentUser(r))
+	if err != nil {
+		writePurchaseError(w, err, "failed to create purchase")
+		return
+	}
+	writeJSON(w, http.StatusCreated, purchase)
+}
+
+func (s *Server) handleUpdatePurchase(w http.ResponseWriter, r *http.Request) {
+	var input store.PurchaseInvoiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if err := validatePurchase(&input); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	purchase, err := s.Store.UpdatePurchase(r.Context(), chi.URLParam(r, "purchaseId"), input)
+	if err != nil {
+		writePurchaseError(w, err, "failed to update purchase")
+		return
+	}
+	writeJSON(w, http.StatusOK, purchase)
+}
+
+func (s *Server) handleDeletePurchase(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeletePurchase(r.Context(), chi.URLParam(r, "purchaseId")); err != nil {
+		writePurchaseError(w, err, "failed to delete purchase")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePostPurchase books a draft into stock. It may change item prices,
+// so the item cache is dropped.
+func (s *Server) handlePostPurchase(w http.ResponseWriter, r *http.Request) {
+	purchase, err := s.Store.PostPurchase(r.Context(), chi.URLParam(r, "purchaseId"), currentUser(r))
+	if err != nil {
+		writePurchaseError(w, err, "failed to post purchase")
+		return
+	}
+	if purchase.UpdatePrices {
+		s.Cache.Invalidate("items:")
+	}
+	writeJSON(w, http.StatusOK, purchase)
+}
+
+// validatePurchase trims the input and checks the header and lines.
+func validatePurchase(input *store.PurchaseInvoiceInput) error {
+	input.SupplierID = strings.TrimSpace(input.SupplierID)
+	if input.SupplierID == "" {
+		return errors.New("supplierId is required")
+	}
+	for _, field := range []**string{&input.InvoiceNumber, &input.Note} {
+		if *field != nil {
+			if v := strings.TrimSpace(**field); v == "" {
+				*field = nil
+			} else {
+				*field = &v
+			}
+		}
+	}
+	input.InvoiceDate = strings.TrimSpace(input.InvoiceDate)
+	if input.InvoiceDate != "" {
+		if _, err := time.Parse("2006-01-02", input.InvoiceDate); err != nil {
+			return errors.New("invoiceDate must be a date (YYYY-MM-DD)")
+		}
+	}
+	if input.ExtraCosts < 0 {
+		return errors.New("extraCosts must not be negative")
+	}
+	if len(input.Lines) == 0 {
+		return errors.New("purchase lines are required")
+	}
+	for i := range input.Lines {
+		line := &input.Lines[i]
+		line.ItemID = strings.TrimSpace(line.ItemID)
+		line.Unit = strings.TrimSpace(line.Unit)
+		if line.ItemID == "" {
+			return errors.New("itemId is required on every line")
+		}
+		if line.Quantity <= 0 {
+			return errors.New("quantity must be positive")
+		}
+		if line.UnitCost < 0 {
+			return errors.New("unitCost must not be negative")
+		}
+	}
+	return nil
+}
+
+func writePurchaseError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case store.ErrConflict:
+		writeError(w, http.StatusConflict, "purchase is already posted or its invoice number is taken")
+	case store.ErrInvalidReference:
+		writeError(w, http.StatusBadRequest, "supplier not found")
+	default:
+		writeStoreError(w, err, "purchase not found", fallback)
+	}
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleSalesByCategory(w http.ResponseWriter, r *http.Request) {
+	period, err := parseReportRange(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rollup := strings.ToLower(r.URL.Query().Get("rollup")) == "true"
+
+	report, err := s.Store.SalesByCategory(r.Context(), period, rollup)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) handleSalesByBrand(w http.ResponseWriter, r *http.Request) {
+	period, err := parseReportRange(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := s.Store.SalesByBrand(r.Context(), period)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleSalesSummary totals bills per day, week or month for the dashboard,
+// optionally for one customer, item or category.
+func (s *Server) handleSalesSummary(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSalesFilter(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	groupBy := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("groupBy")))
+	if groupBy == "" {
+		groupBy = store.GroupByDay
+	}
+
+	summary, err := s.Store.SalesSummary(r.Context(), filter, groupBy)
+	if err != nil {
+		var invalidErr *store.InvalidError
+		if errors.As(err, &invalidErr) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// handleProfitReport shows gross profit per bill, item, category, customer
+// or period at the cost each line was sold at, flagging lines below cost.
+func (s *Server) handleProfitReport(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSalesFilter(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	groupBy := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("groupBy")))
+	if groupBy == "" {
+		groupBy = store.GroupByItem
+	}
+
+	report, err := s.Store.ProfitReport(r.Context(), filter, groupBy)
+	if err != nil {
+		var invalidErr *store.InvalidError
+		if errors.As(err, &invalidErr) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handlePriceChanges lists item prices that moved since a date, for checking
+// before re-quoting an old customer.
+func (s *Server) handlePriceChanges(w http.ResponseWriter, r *http.Request) {
+	v := strings.TrimSpace(r.URL.Query().Get("since"))
+	if v == "" {
+		writeError(w, http.StatusBadRequest, "since is required")
+		return
+	}
+	since, err := time.ParseInLocation("2006-01-02", v, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "since must be a date (YYYY-MM-DD)")
+		return
+	}
+
+	report, err := s.Store.PriceChangesSince(r.Context(), since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleReorderReport returns the low-stock list built by the daily job, or
+// rebuilds it first with refresh=true.
+func (s *Server) handleReorderReport(w http.ResponseWriter, r *http.Request) {
+	if strings.ToLower(r.URL.Query().Get("refresh")) == "true" {
+		if err := s.Store.RefreshReorderList(r.Context(), s.Config.ReorderWindowDays, s.Config.ReorderLeadDays); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to refresh reorder list")
+			return
+		}
+	}
+
+	list, err := s.Store.ListReorderSuggestions(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handlePriceOverrides lists bill prices typed in by hand, newest first,
+// with belowMargin=true keeping only those under the item's minimum margin.
+func (s *Server) handlePriceOverrides(w http.ResponseWriter, r *http.Request) {
+	period, err := parseReportRange(r, s.Config.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	filter := store.PriceOverrideFilter{
+		From:        period.From,
+		To:          period.To,
+		ItemID:      strings.TrimSpace(q.Get("itemId")),
+		BelowMargin: strings.ToLower(q.Get("belowMargin")) == "true",
+	}
+	limit, offset := 50, 0
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	overrides, err := s.Store.ListPriceOverrides(r.Context(), filter, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	writeJSON(w, http.StatusOK, overrides)
+}
+
+// parseSalesFilter reads the filters shared by the sales and profit
+// reports: the report range and a customer, item, category or branch.
+func parseSalesFilter(r *http.Request, loc *time.Location) (store.SalesFilter, error) {
+	period, err := parseReportRange(r, loc)
+	if err != nil {
+		return store.SalesFilter{}, err
+	}
+	q := r.URL.Query()
+	return store.SalesFilter{
+		ReportRange: period,
+		CustomerID:  strings.TrimSpace(q.Get("customerId")),
+		ItemID:      strings.TrimSpace(q.Get("itemId")),
+		CategoryID:  strings.TrimSpace(q.Get("categoryId")),
+		BranchID:    strings.TrimSpace(q.Get("branchId")),
+	}, nil
+}
+
+// parseReportRange reads the from/to query parameters as YYYY-MM-DD dates.
+// Both ends are inclusive days, starting and ending at midnight in loc.
+func parseReportRange(r *http.Request, loc *time.Location) (store.ReportRange, error) {
+	var period store.ReportRange
+	if v := strings.TrimSpace(r.URL.Query().Get("from")); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return period, errors.New("from must be a date (YYYY-MM-DD)")
+		}
+		period.From = &from
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("to")); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return period, errors.New("to must be a date (YYYY-MM-DD)")
+		}
+		to = to.AddDate(0, 0, 1)
+		period.To = &to
+	}
+	if period.From != nil && period.To != nil && !period.From.Before(*period.To) {
+		return period, errors.New("from must not be after to")
+	}
+	return period, nil
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"subahan-billing-backend/internal/pricing"
+	"subahan-billing-backend/internal/store"
+)
+
+type repriceRequest struct {
+	Filter struct {
+		All        bool     `json:"all"`
+		CategoryID string   `json:"categoryId"`
+		BrandID    string   `json:"brandId"`
+		IsWireBox  *bool    `json:"isWireBox"`
+		ItemIDs    []string `json:"itemIds"`
+		Name       string   `json:"name"`
+	} `json:"filter"`
+	// Field is buyingPrice or sellingPrice; Percent and Amount adjust it
+	Field              string   `json:"field"`
+	Percent            *float64 `json:"percent"`
+	Amount             *float64 `json:"amount"`
+	PurchasePercentage *float64 `json:"purchasePercentage"`
+	SellPercentage     *float64 `json:"sellPercentage"`
+	Rounding           struct {
+		Step float64 `json:"step"`
+		Mode string  `json:"mode"`
+	} `json:"rounding"`
+	DryRun bool `json:"dryRun"`
+}
+
+type repriceReport struct {
+	DryRun  bool                  `json:"dryRun"`
+	Applied bool                  `json:"applied"`
+	Changed int                   `json:"changed"`
+	Skipped int                   `json:"skipped"`
+	Items   []store.RepriceResult `json:"items"`
+}
+
+// handleRepriceItems adjusts prices for every item matching a filter. A dry
+// run returns the diff without writing; a real run applies the same diff in
+// one transaction and records it in the price history.
+func (s *Server) handleRepriceItems(w http.ResponseWriter, r *http.Request) {
+	var req repriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	filter, err := repriceFilter(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reprice, err := repriceFunc(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := s.Store.RepriceItems(r.Context(), filter, reprice, !req.DryRun, currentUser(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to reprice items")
+		return
+	}
+
+	report := repriceReport{DryRun: req.DryRun, Applied: !req.DryRun, Items: results}
+	for _, result := range results {
+		if result.Skipped != "" {
+			report.Skipped++
+		} else {
+			report.Changed++
+		}
+	}
+	if report.Applied && report.Changed > 0 {
+		s.Cache.Invalidate("items:")
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func repriceFilter(req repriceRequest) (store.ItemFilter, error) {
+	f := req.Filter
+	filter := store.ItemFilter{
+		CategoryID: strings.TrimSpace(f.CategoryID),
+		BrandID:    strings.TrimSpace(f.BrandID),
+		IsWireBox:  f.IsWireBox,
+		Name:       strings.TrimSpace(f.Name),
+	}
+	if len(f.ItemIDs) > 0 {
+		filter.ItemIDs = make([]string, 0, len(f.ItemIDs))
+		for _, id := range f.ItemIDs {
+			if id = strings.TrimSpace(id); id != "" {
+				filter.ItemIDs = append(filter.ItemIDs, id)
+			}
+		}
+	}
+	// Guard against repricing the whole catalog by leaving the filter out
+	if !f.All && filter.CategoryID == "" && filter.BrandID == "" && filter.IsWireBox == nil && len(filter.ItemIDs) == 0 && filter.Name == "" {
+		return filter, errors.New("filter is required (set filter.all to reprice every item)")
+	}
+	return filter, nil
+}
+
+// repriceFunc validates the request and returns the per-item price rule.
+func repriceFunc(req repriceRequest) (func(store.Item) (store.ItemPrices, error), error) {
+	adjust := req.Percent != nil || req.Amount != nil
+	switch {
+	case req.Percent != nil && req.Amount != nil:
+		return nil, errors.New("use either percent or amount, not both")
+	case adjust && req.Field != store.PriceFieldBuying && req.Field != store.PriceFieldSelling:
+		return nil, errors.New("field must be buyingPrice or sellingPrice")
+	case !adjust && req.PurchasePercentage == nil && req.SellPercentage == nil:
+		return nil, errors.New("nothing to change: give percent, amount, purchasePercentage or sellPercentage")
+	}
+	for _, p := range []*float64{req.PurchasePercentage, req.SellPercentage} {
+		if p != nil && (*p < 0 || *p > 100) {
+			return nil, errors.New("purchasePercentage and sellPercentage must be between 0 and 100")
+		}
+	}
+	round, err := priceRounder(req.Rounding.Step, req.Rounding.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	change := func(v float64) float64 {
+		if req.Percent != nil {
+			return round(v * (1 + *req.Percent/100))
+		}
+		return round(v + *req.Amount)
+	}
+
+	return func(item store.Item) (store.ItemPrices, error) {
+		prices := store.ItemPrices{
+			BuyingPrice:        item.BuyingPrice,
+			SellingPrice:       item.SellingPrice,
+			PurchasePercentage: item.PurchasePercentage,
+			SellPercentage:     item.SellPercentage,
+		}
+		if !adjust && !item.IsWireBox {
+			return prices, errors.New("percentages only apply to Wire/Box items")
+		}
+
+		if adjust {
+			switch req.Field {
+			case store.PriceFieldBuying:
+				if item.BuyingPrice == nil {
+					return prices, errors.New("item has no buying price")
+				}
+				buying := change(*item.BuyingPrice)
+				prices.BuyingPrice = &buying
+			case store.PriceFieldSelling:
+				if item.IsWireBox {
+					return prices, errors.New("selling price of Wire/Box items follows sellPercentage")
+				}
+				prices.SellingPrice = change(item.SellingPrice)
+			}
+		}
+
+		if item.IsWireBox {
+			if req.PurchasePercentage != nil {
+				prices.PurchasePercentage = req.PurchasePercentage
+			}
+			if req.SellPercentage != nil {
+				prices.SellPercentage = req.SellPercentage
+			}
+			if prices.BuyingPrice == nil || prices.SellPercentage == nil {
+				return prices, errors.New("item is missing its base price or sellPercentage")
+			}
+			prices.SellingPrice = round(pricing.WireBoxSellingPrice(*prices.BuyingPrice, *prices.SellPercentage))
+		}
+
+		if (prices.BuyingPrice != nil && *prices.BuyingPrice <= 0) || prices.SellingPrice <= 0 {
+			return prices, errors.New("new price would not be positive")
+		}
+		return prices, nil
+	}, nil
+}
+
+// priceRounder rounds to a multiple of step (default 0.001 KWD, one fils)
+// in the given direction: nearest (default), up or down.
+func priceRounder(step float64, mode string) (func(float64) float64, error) {
+	if step == 0 {
+		step = 0.001
+	}
+	if step < 0.001 || step > 100 {
+		return nil, errors.New("rounding.step must be between 0.001 and 100")
+	}
+	var fn func(float64) float64
+	switch strings.ToLower(mode) {
+	case "", "nearest":
+		fn = math.Round
+	case "up":
+		// The tolerance keeps float noise such as 1.0700000001 from rounding up a whole step
+		fn = func(v float64) float64 { return math.Ceil(v - 1e-9) }
+	case "down":
+		fn = func(v float64) float64 { return math.Floor(v + 1e-9) }
+	default:
+		return nil, errors.New("rounding.mode must be nearest, up or down")
+	}
+	return func(v float64) float64 {
+		return math.Round(fn(v/step)*step*1000) / 1000
+	}, nil
+}
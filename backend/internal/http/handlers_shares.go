@@ -0,0 +1,220 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"subahan-billing-backend/internal/invoice"
+	"subahan-billing-backend/internal/store"
+)
+
+const (
+	shareTokenType  = "bill_share"
+	defaultShareTTL = 7 * 24 * time.Hour
+	maxShareTTL     = 90 * 24 * time.Hour
+)
+
+type createShareRequest struct {
+	ExpiresInHours int `json:"expiresInHours"`
+}
+
+type shareResponse struct {
+	store.BillShare
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// publicBill is the customer-facing view of a bill: no costs, percentages
+// or contact details.
+type publicBill struct {
+	InvoiceNumber string           `json:"invoiceNumber"`
+	Customer      *string          `json:"customer"`
+	TotalAmount   float64          `json:"totalAmount"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	Items         []publicBillItem `json:"items"`
+}
+
+type publicBillItem struct {
+	ItemID     string  `json:"itemId"`
+	ItemName   string  `json:"itemName"`
+	ArabicName string  `json:"arabicName"`
+	Unit       string  `json:"unit"`
+	Quantity   int     `json:"quantity"`
+	UnitPrice  float64 `json:"unitPrice"`
+	Amount     float64 `json:"amount"`
+	Promotion  *string `json:"promotion"`
+}
+
+func (s *Server) handleCreateBillShare(w http.ResponseWriter, r *http.Request) {
+	billID := chi.URLParam(r, "billId")
+	var req createShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+
+	ttl := defaultShareTTL
+	if req.ExpiresInHours != 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+		if ttl <= 0 || ttl > maxShareTTL {
+			writeError(w, http.StatusBadRequest, "expiresInHours must be between 1 and 2160")
+			return
+		}
+	}
+
+	share, err := s.Store.CreateBillShare(r.Context(), billID, time.Now().Add(ttl), currentUser(r))
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "bill not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create share link")
+		return
+	}
+
+	token, err := issueShareToken(s.Config.JWTSecret, share)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "unable to sign share link")
+		return
+	}
+	writeJSON(w, http.StatusCreated, shareResponse{BillShare: share, Token: token, Path: "/api/public/bills/" + token})
+}
+
+func (s *Server) handleListBillShares(w http.ResponseWriter, r *http.Request) {
+	billID := chi.URLParam(r, "billId")
+	shares, err := s.Store.ListBillShares(r.Context(), billID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load share links")
+		return
+	}
+	writeJSON(w, http.StatusOK, shares)
+}
+
+func (s *Server) handleRevokeBillShare(w http.ResponseWriter, r *http.Request) {
+	billID := chi.URLParam(r, "billId")
+	shareID := chi.URLParam(r, "shareId")
+	if err := s.Store.RevokeBillShare(r.Context(), billID, shareID); err != nil {
+		writeError(w, http.StatusNotFound, "share link not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func (s *Server) handlePublicBill(w http.ResponseWriter, r *http.Request) {
+	bill, ok := s.sharedBill(w, r)
+	if !ok {
+		return
+	}
+
+	view := publicBill{
+		InvoiceNumber: invoice.Number(bill),
+		Customer:      bill.Customer,
+		TotalAmount:   bill.TotalAmount,
+		CreatedAt:     bill.CreatedAt,
+		Items:         make([]publicBillItem, 0, len(bill.Items)),
+	}
+	for _, line := range bill.Items {
+		view.Items = append(view.Items, publicBillItem{
+			ItemID:     line.ItemID,
+			ItemName:   line.ItemName,
+			ArabicName: line.ArabicName,
+			Unit:       line.Unit,
+			Quantity:   line.Quantity,
+			UnitPrice:  line.UnitPrice,
+			Amount:     line.UnitPrice * float64(line.Quantity),
+			Promotion:  line.PromotionName,
+		})
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+func (s *Server) handlePublicBillPDF(w http.ResponseWriter, r *http.Request) {
+	bill, ok := s.sharedBill(w, r)
+	if !ok {
+		return
+	}
+	pdf, err := invoice.RenderPDF(bill)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to render invoice")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="invoice-`+invoice.Number(bill)+`.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pdf)
+}
+
+// sharedBill resolves the share token in the URL to its bill. It writes the
+// error response itself and reports false when the link is unusable.
+func (s *Server) sharedBill(w http.ResponseWriter, r *http.Request) (store.Bill, bool) {
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	shareID, billID, err := parseShareToken(s.Config.JWTSecret, chi.URLParam(r, "token"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "link is invalid or has expired")
+		return store.Bill{}, false
+	}
+	share, err := s.Store.UseBillShare(r.Context(), shareID)
+	if err != nil || share.BillID != billID {
+		writeError(w, http.StatusNotFound, "link is invalid or has expired")
+		return store.Bill{}, false
+	}
+	bill, err := s.Store.GetBill(r.Context(), share.BillID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "link is invalid or has expired")
+		return store.Bill{}, false
+	}
+	return bill, true
+}
+
+// shareSigningKey derives a key from the JWT secret so that share tokens can
+// never be replayed as admin tokens against authMiddleware.
+func shareSigningKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(shareTokenType))
+	return mac.Sum(nil)
+}
+
+func issueShareToken(secret string, share store.BillShare) (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  share.ID,
+		"bill": share.BillID,
+		"typ":  shareTokenType,
+		"exp":  share.ExpiresAt.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(shareSigningKey(secret))
+}
+
+func parseShareToken(secret, tokenStr string) (shareID, billID string, err error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return shareSigningKey(secret), nil
+	}, jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return "", "", errors.New("invalid share token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", errors.New("invalid share token claims")
+	}
+	if typ, _ := claims["typ"].(string); typ != shareTokenType {
+		return "", "", errors.New("not a share token")
+	}
+	shareID, _ = claims["sub"].(string)
+	billID, _ = claims["bill"].(string)
+	if shareID == "" || billID == "" {
+		return "", "", errors.New("incomplete share token")
+	}
+	return shareID, billID, nil
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleGetStock(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	level, err := s.Store.GetStock(r.Context(), chi.URLParam(r, "itemId"), limit)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load stock")
+		return
+	}
+	writeJSON(w, http.StatusOK, level)
+}
+
+// handleRecordStock books a purchase (positive quantity) or a manual
+// adjustment (either sign) against an item.
+func (s *Server) handleRecordStock(w http.ResponseWriter, r *http.Request) {
+	var change store.StockChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	change.ItemID = chi.URLParam(r, "itemId")
+	change.Unit = strings.TrimSpace(change.Unit)
+	switch change.Kind {
+	case store.StockPurchase:
+		if change.Quantity <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be positive for a purchase")
+			return
+		}
+	case store.StockAdjustment:
+		if change.Quantity == 0 {
+			writeError(w, http.StatusBadRequest, "quantity must not be zero")
+			return
+		}
+		if change.Note == nil || strings.TrimSpace(*change.Note) == "" {
+			writeError(w, http.StatusBadRequest, "note is required for an adjustment")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "kind must be purchase or adjustment")
+		return
+	}
+
+	movement, err := s.Store.RecordStockChange(r.Context(), change, currentUser(r))
+	if err != nil {
+		writeStockError(w, err, "item not found", "failed to record stock")
+		return
+	}
+	writeJSON(w, http.StatusCreated, movement)
+}
+
+func (s *Server) handleSetReorder(w http.ResponseWriter, r *http.Request) {
+	var settings store.ReorderSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if settings.ReorderPoint != nil && *settings.ReorderPoint < 0 {
+		writeError(w, http.StatusBadRequest, "reorderPoint must not be negative")
+		return
+	}
+	if settings.ReorderQuantity != nil && *settings.ReorderQuantity <= 0 {
+		writeError(w, http.StatusBadRequest, "reorderQuantity must be positive")
+		return
+	}
+
+	itemID := chi.URLParam(r, "itemId")
+	if err := s.Store.SetReorderSettings(r.Context(), itemID, settings); err != nil {
+		writeStockError(w, err, "item not found", "failed to save reorder settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+type returnRequest struct {
+	Items []store.StockChange `json:"items"`
+	Note  *string             `json:"note"`
+}
+
+// handleReturnBillItems puts items a customer brought back into stock.
+func (s *Server) handleReturnBillItems(w http.ResponseWriter, r *http.Request) {
+	var req returnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if len(req.Items) == 0 {
+		writeError(w, http.StatusBadRequest, "items are required")
+		return
+	}
+	for i := range req.Items {
+		if req.Items[i].Quantity <= 0 {
+			writeError(w, http.StatusBadRequest, "quantity must be positive")
+			return
+		}
+		req.Items[i].Unit = strings.TrimSpace(req.Items[i].Unit)
+		if req.Items[i].Note == nil {
+			req.Items[i].Note = req.Note
+		}
+	}
+
+	movements, err := s.Store.ReturnBillItems(r.Context(), chi.URLParam(r, "billId"), req.Items, currentUser(r))
+	if err != nil {
+		writeStockError(w, err, "bill or item not found", "failed to record return")
+		return
+	}
+	writeJSON(w, http.StatusCreated, movements)
+}
+
+func writeStockError(w http.ResponseWriter, err error, notFound, fallback string) {
+	var stockErr *store.StockError
+	if errors.As(err, &stockErr) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeStoreError(w, err, notFound, fallback)
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := s.Store.ListSuppliers(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load suppliers")
+		return
+	}
+	writeJSON(w, http.StatusOK, suppliers)
+}
+
+func (s *Server) handleGetSupplier(w http.ResponseWriter, r *http.Request) {
+	supplier, err := s.Store.GetSupplier(r.Context(), chi.URLParam(r, "supplierId"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "supplier not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, supplier)
+}
+
+func (s *Server) handleCreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var input store.SupplierInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateSupplier(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	supplier, err := s.Store.CreateSupplier(r.Context(), input)
+	if err != nil {
+		writeSupplierError(w, err, "failed to create supplier")
+		return
+	}
+	writeJSON(w, http.StatusCreated, supplier)
+}
+
+func (s *Server) handleUpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	var input store.SupplierInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if msg := validateSupplier(&input); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	supplier, err := s.Store.UpdateSupplier(r.Context(), chi.URLParam(r, "supplierId"), input)
+	if err != nil {
+		writeSupplierError(w, err, "failed to update supplier")
+		return
+	}
+	writeJSON(w, http.StatusOK, supplier)
+}
+
+func (s *Server) handleDeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	if err := s.Store.DeleteSupplier(r.Context(), chi.URLParam(r, "supplierId")); err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "supplier has purchase invoices")
+			return
+		}
+		writeStoreError(w, err, "supplier not found", "failed to delete supplier")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// validateSupplier trims the input and blanks empty optional fields.
+func validateSupplier(input *store.SupplierInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "name is required"
+	}
+	for _, field := range []**string{&input.Email, &input.Phone, &input.Notes} {
+		if *field != nil {
+			if v := strings.TrimSpace(**field); v == "" {
+				*field = nil
+			} else {
+				*field = &v
+			}
+		}
+	}
+	if input.Email != nil {
+		if _, err := mail.ParseAddress(*input.Email); err != nil {
+			return "email is not a valid address"
+		}
+	}
+	return ""
+}
+
+func writeSupplierError(w http.ResponseWriter, err error, fallback string) {
+	if err == store.ErrConflict {
+		writeError(w, http.StatusConflict, "a supplier with this name already exists")
+		return
+	}
+	writeStoreError(w, err, "supplier not found", fallback)
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"subahan-billing-backend/internal/store"
+)
+
+func (s *Server) handleListUnits(w http.ResponseWriter, r *http.Request) {
+	units, err := s.Store.ListUnits(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load units")
+		return
+	}
+	writeJSON(w, http.StatusOK, units)
+}
+
+func (s *Server) handleCreateUnit(w http.ResponseWriter, r *http.Request) {
+	var input store.UnitInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	code := strings.TrimSpace(input.Code)
+	if code == "" || len(code) > 20 || strings.ContainsAny(code, " \t\n") {
+		writeError(w, http.StatusBadRequest, "code is required, without spaces, at most 20 characters")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	unit, err := s.Store.CreateUnit(r.Context(), input)
+	if err != nil {
+		if err == store.ErrConflict {
+			writeError(w, http.StatusConflict, "unit already exists")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create unit")
+		return
+	}
+	writeJSON(w, http.StatusCreated, unit)
+}
+
+func (s *Server) handleUpdateUnit(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	var input store.UnitInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload")
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	unit, err := s.Store.UpdateUnit(r.Context(), code, input)
+	if err != nil {
+		if err == store.ErrNotFound {
+			writeError(w, http.StatusNotFound, "unit not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to update unit")
+		return
+	}
+	writeJSON(w, http.StatusOK, unit)
+}
+
+func (s *Server) handleDeleteUnit(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	if err := s.Store.DeleteUnit(r.Context(), code); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			writeError(w, http.StatusNotFound, "unit not found")
+		case store.ErrConflict:
+			writeError(w, http.StatusConflict, "unit is used by items")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to delete unit")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
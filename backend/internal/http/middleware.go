@@ -3,11 +3,14 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"subahan-billing-backend/internal/store"
 )
 
 type contextKey string
@@ -51,6 +54,13 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// currentUser returns the username the request was authenticated as, or ""
+// outside the protected routes.
+func currentUser(r *http.Request) string {
+	username, _ := r.Context().Value(userKey).(string)
+	return username
+}
+
 func corsMiddleware(origin string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -95,6 +105,22 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, map[string]string{"error": message})
 }
 
+// writeStoreError answers the store errors every resource shares: a missing
+// row as 404 with notFound, a refused request as 400 with the store's
+// message and anything else as 500 with fallback. Callers handle their own
+// conflicts and references first.
+func writeStoreError(w http.ResponseWriter, err error, notFound, fallback string) {
+	var invalidErr *store.InvalidError
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, notFound)
+	case errors.As(err, &invalidErr):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
 func jsonEncoder(w http.ResponseWriter) *json.Encoder {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
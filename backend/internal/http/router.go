@@ -9,6 +9,8 @@ import (
 
 	"subahan-billing-backend/internal/cache"
 	"subahan-billing-backend/internal/config"
+	"subahan-billing-backend/internal/invoice"
+	"subahan-billing-backend/internal/media"
 	"subahan-billing-backend/internal/store"
 )
 
@@ -16,12 +18,19 @@ type Server struct {
 	Config *config.Config
 	Store  *store.Store
 	Cache  *cache.Cache
+	Mailer *invoice.Mailer
+	Media  media.Storage
 }
 
-func NewServer(cfg *config.Config, store *store.Store, cache *cache.Cache) *Server {
-	return &Server{Config: cfg, Store: store, Cache: cache}
+func NewServer(cfg *config.Config, store *store.Store, cache *cache.Cache, mailer *invoice.Mailer, files media.Storage) *Server {
+	return &Server{Config: cfg, Store: store, Cache: cache, Mailer: mailer, Media: files}
 }
 
+const (
+	requestTimeout = 30 * time.Second
+	exportTimeout  = 10 * time.Minute
+)
+
 func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
 	if s.Config.CORSOrigin != "" {
@@ -31,25 +40,138 @@ func (s *Server) Router() http.Handler {
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
+
+	// Exports stream whole tables, so they get longer than other requests
+	r.Route("/api/exports", func(exports chi.Router) {
+		exports.Use(middleware.Timeout(exportTimeout))
+		exports.Use(s.authMiddleware)
+		exports.Get("/items", s.handleExportItems)
+		exports.Get("/bills", s.handleExportBills)
+		exports.Get("/bill-lines", s.handleExportBillLines)
+	})
 
 	r.Route("/api", func(api chi.Router) {
+		api.Use(middleware.Timeout(requestTimeout))
 		api.Post("/auth/login", s.handleLogin)
 
+		// Share links carry their own signed token; no admin JWT is involved.
+		api.Get("/public/bills/{token}", s.handlePublicBill)
+		api.Get("/public/bills/{token}/pdf", s.handlePublicBillPDF)
+
+		// Item photos are served without login so they can be embedded directly.
+		api.Get("/media/items/{itemId}/images/{imageId}", s.handleItemMedia)
+
 		api.Group(func(protected chi.Router) {
 			protected.Use(s.authMiddleware)
 			protected.Get("/items", s.handleListItems)
+			protected.Get("/items/trash", s.handleListTrash)
 			protected.Get("/items/{itemId}", s.handleGetItem)
+			protected.Get("/items/by-code/{code}", s.handleGetItemByCode)
 			protected.Post("/items", s.handleCreateItem)
+			protected.Post("/items/import", s.handleImportItems)
+			protected.Post("/items/reprice", s.handleRepriceItems)
 			protected.Put("/items/{itemId}", s.handleUpdateItem)
+			protected.Get("/items/{itemId}/price-history", s.handleItemPriceHistory)
+			protected.Get("/items/{itemId}/price", s.handleQuoteItemPrice)
+			protected.Get("/items/{itemId}/stock", s.handleGetStock)
+			protected.Post("/items/{itemId}/stock", s.handleRecordStock)
+			protected.Put("/items/{itemId}/reorder", s.handleSetReorder)
+			protected.Put("/items/{itemId}/price-index", s.handleLinkItemPriceIndex)
+			protected.Put("/items/{itemId}/min-margin", s.handleSetItemMinMargin)
+			protected.Get("/items/{itemId}/components", s.handleGetKitComponents)
+			protected.Put("/items/{itemId}/components", s.handleSetKitComponents)
 			protected.Delete("/items/{itemId}", s.handleDeleteItem)
 			protected.Post("/items/{itemId}/restore", s.handleRestoreItem)
+			protected.Post("/items/{itemId}/merge", s.handleMergeItems)
+			protected.Get("/items/{itemId}/images", s.handleListItemImages)
+			protected.Post("/items/{itemId}/images", s.handleUploadItemImage)
+			protected.Delete("/items/{itemId}/images/{imageId}", s.handleDeleteItemImage)
+
+			protected.Get("/categories", s.handleListCategories)
+			protected.Post("/categories", s.handleCreateCategory)
+			protected.Put("/categories/{categoryId}", s.handleUpdateCategory)
+			protected.Put("/categories/{categoryId}/min-margin", s.handleSetCategoryMinMargin)
+			protected.Delete("/categories/{categoryId}", s.handleDeleteCategory)
+
+			protected.Get("/brands", s.handleListBrands)
+			protected.Post("/brands", s.handleCreateBrand)
+			protected.Put("/brands/{brandId}", s.handleUpdateBrand)
+			protected.Delete("/brands/{brandId}", s.handleDeleteBrand)
+
+			protected.Get("/units", s.handleListUnits)
+			protected.Post("/units", s.handleCreateUnit)
+			protected.Put("/units/{code}", s.handleUpdateUnit)
+			protected.Delete("/units/{code}", s.handleDeleteUnit)
+
+			protected.Get("/price-lists", s.handleListPriceLists)
+			protected.Post("/price-lists", s.handleCreatePriceList)
+			protected.Put("/price-lists/{priceListId}", s.handleUpdatePriceList)
+			protected.Delete("/price-lists/{priceListId}", s.handleDeletePriceList)
+			protected.Get("/price-lists/{priceListId}/items", s.handleListPriceListItems)
+			protected.Put("/price-lists/{priceListId}/items/{itemId}", s.handleSetPriceListItem)
+			protected.Delete("/price-lists/{priceListId}/items/{itemId}", s.handleDeletePriceListItem)
+
+			protected.Get("/price-indexes", s.handleListPriceIndexes)
+			protected.Post("/price-indexes", s.handleCreatePriceIndex)
+			protected.Put("/price-indexes/{indexId}", s.handleUpdatePriceIndex)
+			protected.Delete("/price-indexes/{indexId}", s.handleDeletePriceIndex)
+			protected.Get("/price-indexes/{indexId}/values", s.handleListPriceIndexValues)
+			protected.Post("/price-indexes/{indexId}/values", s.handlePostPriceIndexValue)
+
+			protected.Get("/quantity-breaks", s.handleListQuantityBreaks)
+			protected.Post("/quantity-breaks", s.handleCreateQuantityBreak)
+			protected.Put("/quantity-breaks/{breakId}", s.handleUpdateQuantityBreak)
+			protected.Delete("/quantity-breaks/{breakId}", s.handleDeleteQuantityBreak)
+
+			protected.Get("/promotions", s.handleListPromotions)
+			protected.Post("/promotions", s.handleCreatePromotion)
+			protected.Get("/promotions/{promotionId}", s.handleGetPromotion)
+			protected.Put("/promotions/{promotionId}", s.handleUpdatePromotion)
+			protected.Delete("/promotions/{promotionId}", s.handleDeletePromotion)
+
+			protected.Get("/customers", s.handleListCustomers)
+			protected.Post("/customers", s.handleCreateCustomer)
+			protected.Get("/customers/{customerId}", s.handleGetCustomer)
+			protected.Put("/customers/{customerId}", s.handleUpdateCustomer)
+			protected.Delete("/customers/{customerId}", s.handleDeleteCustomer)
+
+			protected.Get("/suppliers", s.handleListSuppliers)
+			protected.Post("/suppliers", s.handleCreateSupplier)
+			protected.Get("/suppliers/{supplierId}", s.handleGetSupplier)
+			protected.Put("/suppliers/{supplierId}", s.handleUpdateSupplier)
+			protected.Delete("/suppliers/{supplierId}", s.handleDeleteSupplier)
+
+			protected.Get("/branches", s.handleListBranches)
+			protected.Post("/branches", s.handleCreateBranch)
+			protected.Put("/branches/{branchId}", s.handleUpdateBranch)
+			protected.Delete("/branches/{branchId}", s.handleDeleteBranch)
+
+			protected.Get("/purchases", s.handleListPurchases)
+			protected.Post("/purchases", s.handleCreatePurchase)
+			protected.Get("/purchases/{purchaseId}", s.handleGetPurchase)
+			protected.Put("/purchases/{purchaseId}", s.handleUpdatePurchase)
+			protected.Delete("/purchases/{purchaseId}", s.handleDeletePurchase)
+			protected.Post("/purchases/{purchaseId}/post", s.handlePostPurchase)
 
 			protected.Get("/bills", s.handleListBills)
 			protected.Post("/bills", s.handleCreateBill)
 			protected.Get("/bills/{billId}", s.handleGetBill)
 			protected.Put("/bills/{billId}", s.handleUpdateBill)
 			protected.Delete("/bills/{billId}", s.handleDeleteBill)
+			protected.Post("/bills/{billId}/send", s.handleSendBill)
+			protected.Get("/bills/{billId}/deliveries", s.handleListBillDeliveries)
+			protected.Post("/bills/{billId}/returns", s.handleReturnBillItems)
+			protected.Post("/bills/{billId}/shares", s.handleCreateBillShare)
+			protected.Get("/bills/{billId}/shares", s.handleListBillShares)
+			protected.Delete("/bills/{billId}/shares/{shareId}", s.handleRevokeBillShare)
+
+			protected.Get("/reports/sales", s.handleSalesSummary)
+			protected.Get("/reports/sales/by-category", s.handleSalesByCategory)
+			protected.Get("/reports/profit", s.handleProfitReport)
+			protected.Get("/reports/sales/by-brand", s.handleSalesByBrand)
+			protected.Get("/reports/price-changes", s.handlePriceChanges)
+			protected.Get("/reports/price-overrides", s.handlePriceOverrides)
+			protected.Get("/reports/reorder", s.handleReorderReport)
 		})
 	})
 
@@ -0,0 +1,121 @@
+package invoice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"subahan-billing-backend/internal/mail"
+	"subahan-billing-backend/internal/store"
+)
+
+const (
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = time.Hour
+	retryBatchSize = 20
+)
+
+// Mailer emails rendered invoices and keeps the bill's delivery log up to date.
+type Mailer struct {
+	store       *store.Store
+	sender      mail.Sender
+	maxAttempts int
+}
+
+func NewMailer(store *store.Store, sender mail.Sender, maxAttempts int) *Mailer {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Mailer{store: store, sender: sender, maxAttempts: maxAttempts}
+}
+
+// Send records a new delivery for the bill and makes the first attempt right
+// away. A failed attempt is left on the retry queue rather than returned as an
+// error; only bookkeeping failures are.
+func (m *Mailer) Send(ctx context.Context, billID, recipient, requestedBy string) (store.BillDelivery, error) {
+	delivery, err := m.store.CreateBillDelivery(ctx, billID, recipient, requestedBy)
+	if err != nil {
+		return delivery, err
+	}
+	return m.attempt(ctx, delivery)
+}
+
+// RetryDue re-attempts every delivery whose retry time has passed.
+func (m *Mailer) RetryDue(ctx context.Context) error {
+	for {
+		due, err := m.store.ClaimDueDeliveries(ctx, retryBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range due {
+			if _, err := m.attempt(ctx, delivery); err != nil {
+				return err
+			}
+		}
+		if len(due) < retryBatchSize {
+			return nil
+		}
+	}
+}
+
+func (m *Mailer) attempt(ctx context.Context, delivery store.BillDelivery) (store.BillDelivery, error) {
+	sendErr := m.deliver(ctx, delivery)
+
+	var next *time.Time
+	if sendErr != nil && delivery.Attempts+1 < m.maxAttempts {
+		at := time.Now().Add(retryDelay(delivery.Attempts + 1))
+		next = &at
+	}
+	return m.store.RecordDeliveryAttempt(ctx, delivery.ID, sendErr, next)
+}
+
+func (m *Mailer) deliver(ctx context.Context, delivery store.BillDelivery) error {
+	bill, err := m.store.GetBill(ctx, delivery.BillID)
+	if err != nil {
+		return fmt.Errorf("load bill: %w", err)
+	}
+	msg, err := message(bill, delivery.Recipient)
+	if err != nil {
+		return err
+	}
+	return m.sender.Send(ctx, msg)
+}
+
+// message is the email carrying a bill's invoice to recipient.
+func message(bill store.Bill, recipient string) (mail.Message, error) {
+	pdf, err := RenderPDF(bill)
+	if err != nil {
+		return mail.Message{}, fmt.Errorf("render invoice: %w", err)
+	}
+
+	number := Number(bill)
+	customer := "Customer"
+	if bill.Customer != nil && *bill.Customer != "" {
+		customer = *bill.Customer
+	}
+	body := fmt.Sprintf("Dear %s,\n\nPlease find attached invoice %s dated %s for a total of %.3f K.D.\n\nThank you for your business.\n%s\n",
+		customer, number, bill.CreatedAt.Format("02/01/2006"), bill.TotalAmount, companyName)
+
+	return mail.Message{
+		To:      recipient,
+		Subject: fmt.Sprintf("Invoice %s from %s", number, companyName),
+		Body:    body,
+		Attachments: []mail.Attachment{{
+			Filename:    fmt.Sprintf("invoice-%s.pdf", number),
+			ContentType: "application/pdf",
+			Data:        pdf,
+		}},
+	}, nil
+}
+
+// retryDelay doubles from retryBaseDelay with each attempt, capped at retryMaxDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
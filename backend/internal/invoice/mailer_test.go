@@ -0,0 +1,95 @@
+package invoice
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"subahan-billing-backend/internal/mail"
+	"subahan-billing-backend/internal/mail/mailtest"
+	"subahan-billing-backend/internal/store"
+)
+
+func testBill() store.Bill {
+	customer := "Ahmad Trading"
+	return store.Bill{
+		ID:          "3f2a9c1e-7b4d-4e8a-9c1f-2d3e4f5a6b7c",
+		Customer:    &customer,
+		TotalAmount: 12.5,
+		CreatedAt:   time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC),
+		Items: []store.BillItem{
+			{ItemID: "ITEM001", ItemName: "Cable 2.5mm", Unit: "m", UnitFactor: 1, Quantity: 10, UnitPrice: 0.75},
+			{ItemID: "ITEM002", ItemName: "Switch", Unit: "pcs", UnitFactor: 1, Quantity: 5, UnitPrice: 1},
+		},
+	}
+}
+
+func TestNumber(t *testing.T) {
+	if got := Number(testBill()); got != "3F2A9C1E-7B4D" {
+		t.Errorf("Number = %q", got)
+	}
+	if got := Number(store.Bill{ID: "abc"}); got != "ABC" {
+		t.Errorf("Number of a short ID = %q", got)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	pdf, err := RenderPDF(testBill())
+	if err != nil {
+		t.Fatalf("RenderPDF: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		t.Errorf("output does not start with a PDF header: %q", pdf[:min(len(pdf), 16)])
+	}
+}
+
+func TestMessageOverSMTP(t *testing.T) {
+	msg, err := message(testBill(), "ahmad@example.com")
+	if err != nil {
+		t.Fatalf("message: %v", err)
+	}
+	if msg.Subject != "Invoice 3F2A9C1E-7B4D from "+companyName {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "Dear Ahmad Trading") || !strings.Contains(msg.Body, "04/03/2026") || !strings.Contains(msg.Body, "12.500 K.D.") {
+		t.Errorf("Body = %q", msg.Body)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "invoice-3F2A9C1E-7B4D.pdf" {
+		t.Fatalf("Attachments = %+v", msg.Attachments)
+	}
+
+	server := mailtest.NewServer(t)
+	sender := mail.NewSMTPSender(server.Host, server.Port, "", "", "billing@example.com")
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got := server.Messages()
+	if len(got) != 1 || len(got[0].To) != 1 || got[0].To[0] != "ahmad@example.com" {
+		t.Fatalf("server got %+v", got)
+	}
+	if !strings.Contains(got[0].Data, `filename=invoice-3F2A9C1E-7B4D.pdf`) {
+		t.Error("sent message has no invoice attachment")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
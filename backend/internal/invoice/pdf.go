@@ -0,0 +1,115 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"subahan-billing-backend/internal/store"
+)
+
+// Letterhead lines, kept in step with the printed invoice in the frontend.
+var letterhead = []string{
+	"Electrical Equipments",
+	"Jahra Industrial Area - Transport Area",
+	"Bldg. 14 - Shop No. 21",
+	"C.R. 330574",
+	"Mob. : 99333505",
+}
+
+const companyName = "SUBHAN Co."
+
+// Number returns the human-facing invoice number for a bill.
+func Number(bill store.Bill) string {
+	id := bill.ID
+	if len(id) > 13 {
+		id = id[:13]
+	}
+	return strings.ToUpper(id)
+}
+
+// RenderPDF draws the bill as an A4 invoice. The built-in PDF fonts cannot
+// shape Arabic, so lines use the English item name.
+func RenderPDF(bill store.Bill) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 15)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, companyName, "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range letterhead {
+		pdf.CellFormat(0, 4.5, line, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, "CASH / CREDIT INVOICE", "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(25, 6, "NO.", "", 0, "L", false, 0, "")
+	pdf.CellFormat(80, 6, Number(bill), "", 0, "L", false, 0, "")
+	pdf.CellFormat(25, 6, "Date:", "", 0, "R", false, 0, "")
+	pdf.CellFormat(0, 6, bill.CreatedAt.Format("02/01/2006"), "", 1, "L", false, 0, "")
+	customer := ""
+	if bill.Customer != nil {
+		customer = *bill.Customer
+	}
+	pdf.CellFormat(25, 6, "Mr./M/s.", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr(customer), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{10, 28, 76, 16, 14, 21, 21}
+	headers := []string{"#", "Item", "Description", "Unit", "Qty", "Price", "Amount"}
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(247, 240, 226)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for i, line := range bill.Items {
+		unit := line.Unit
+		if unit == "" {
+			unit = "pcs"
+		}
+		description := line.ItemName
+		if line.PromotionName != nil {
+			description += " (" + *line.PromotionName + ")"
+		}
+		cells := []string{
+			fmt.Sprintf("%d", i+1),
+			line.ItemID,
+			tr(description),
+			tr(unit),
+			fmt.Sprintf("%d", line.Quantity),
+			fmt.Sprintf("%.3f", line.UnitPrice),
+			fmt.Sprintf("%.3f", line.UnitPrice*float64(line.Quantity)),
+		}
+		aligns := []string{"C", "L", "L", "C", "C", "R", "R"}
+		for j, c := range cells {
+			pdf.CellFormat(widths[j], 6, c, "1", 0, aligns[j], false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	labelWidth := 0.0
+	for _, w := range widths[:len(widths)-1] {
+		labelWidth += w
+	}
+	pdf.CellFormat(labelWidth, 8, "Total K.D.", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(widths[len(widths)-1], 8, fmt.Sprintf("%.3f", bill.TotalAmount), "1", 1, "R", true, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
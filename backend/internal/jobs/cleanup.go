@@ -5,10 +5,11 @@ import (
 	"log"
 	"time"
 
+	"subahan-billing-backend/internal/media"
 	"subahan-billing-backend/internal/store"
 )
 
-func StartItemCleanup(ctx context.Context, store *store.Store) {
+func StartItemCleanup(ctx context.Context, store *store.Store, files media.Storage) {
 	ticker := time.NewTicker(time.Hour)
 	go func() {
 		for {
@@ -17,6 +18,9 @@ func StartItemCleanup(ctx context.Context, store *store.Store) {
 				if err := store.CleanupDeletedItems(ctx); err != nil {
 					log.Printf("cleanup failed: %v", err)
 				}
+				if err := removeOrphanedImages(ctx, store, files); err != nil {
+					log.Printf("image cleanup failed: %v", err)
+				}
 			case <-ctx.Done():
 				ticker.Stop()
 				return
@@ -24,3 +28,26 @@ func StartItemCleanup(ctx context.Context, store *store.Store) {
 		}
 	}()
 }
+
+// removeOrphanedImages deletes the files of images whose item was purged or
+// which were deleted, then forgets them. Images whose files could not be
+// removed are kept for the next run.
+func removeOrphanedImages(ctx context.Context, store *store.Store, files media.Storage) error {
+	ids, err := store.OrphanedImages(ctx)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	removed := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if err := files.Delete(ctx, media.OriginalKey(id)); err != nil {
+			log.Printf("image %s: %v", id, err)
+			continue
+		}
+		if err := files.Delete(ctx, media.ThumbnailKey(id)); err != nil {
+			log.Printf("image %s: %v", id, err)
+			continue
+		}
+		removed = append(removed, id)
+	}
+	return store.ForgetImages(ctx, removed)
+}
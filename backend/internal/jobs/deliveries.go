@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"subahan-billing-backend/internal/invoice"
+)
+
+func StartDeliveryRetry(ctx context.Context, mailer *invoice.Mailer) {
+	ticker := time.NewTicker(time.Minute)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := mailer.RetryDue(ctx); err != nil {
+					log.Printf("delivery retry failed: %v", err)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"subahan-billing-backend/internal/store"
+)
+
+// StartReorderList rebuilds the low-stock list at startup and then daily.
+func StartReorderList(ctx context.Context, store *store.Store, windowDays, leadDays int) {
+	refresh := func() {
+		if err := store.RefreshReorderList(ctx, windowDays, leadDays); err != nil {
+			log.Printf("reorder list refresh failed: %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(24 * time.Hour)
+	go func() {
+		refresh()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
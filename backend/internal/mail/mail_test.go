@@ -0,0 +1,118 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"subahan-billing-backend/internal/mail/mailtest"
+)
+
+func TestSMTPSenderSend(t *testing.T) {
+	server := mailtest.NewServer(t)
+	sender := NewSMTPSender(server.Host, server.Port, "", "", "billing@example.com")
+
+	msg := Message{
+		To:      "customer@example.com",
+		Subject: "Invoice 1 — فاتورة",
+		Body:    "Please find attached.",
+		Attachments: []Attachment{{
+			Filename:    "invoice-1.pdf",
+			ContentType: "application/pdf",
+			Data:        []byte(strings.Repeat("%PDF-1.3 ", 40)),
+		}},
+	}
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := server.Messages()
+	if len(got) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(got))
+	}
+	if got[0].From != "billing@example.com" {
+		t.Errorf("MAIL FROM = %q", got[0].From)
+	}
+	if len(got[0].To) != 1 || got[0].To[0] != msg.To {
+		t.Errorf("RCPT TO = %v", got[0].To)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got[0].Data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Content-Type: %v", err)
+	}
+
+	var parts []string
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		raw, _ := io.ReadAll(part)
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", ""))
+		if err != nil {
+			t.Fatalf("part is not base64: %v", err)
+		}
+		parts = append(parts, string(decoded))
+		if part.FileName() != "" && part.FileName() != "invoice-1.pdf" {
+			t.Errorf("attachment filename = %q", part.FileName())
+		}
+	}
+	if len(parts) != 2 || parts[0] != msg.Body || parts[1] != string(msg.Attachments[0].Data) {
+		t.Errorf("parts = %q", parts)
+	}
+}
+
+func TestSMTPSenderAuth(t *testing.T) {
+	server := mailtest.NewServer(t)
+	sender := NewSMTPSender(server.Host, server.Port, "user", "secret", "billing@example.com")
+	// net/smtp only sends PLAIN credentials in the clear to localhost
+	sender.Host = "localhost"
+	sender.Port = server.Port
+
+	if err := sender.Send(context.Background(), Message{To: "a@example.com", Subject: "s", Body: "b"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	auths := server.Auths()
+	if len(auths) != 1 {
+		t.Fatalf("got %d AUTH commands, want 1", len(auths))
+	}
+	creds, _ := base64.StdEncoding.DecodeString(auths[0])
+	if string(creds) != "\x00user\x00secret" {
+		t.Errorf("AUTH PLAIN = %q", creds)
+	}
+}
+
+func TestSMTPSenderErrors(t *testing.T) {
+	server := mailtest.NewServer(t)
+	sender := NewSMTPSender(server.Host, server.Port, "", "", "billing@example.com")
+
+	if err := sender.Send(context.Background(), Message{Subject: "s"}); err == nil {
+		t.Error("Send without a recipient succeeded")
+	}
+
+	server.RejectRecipients()
+	if err := sender.Send(context.Background(), Message{To: "nobody@example.com", Subject: "s"}); err == nil {
+		t.Error("Send to a rejected recipient succeeded")
+	}
+	if n := len(server.Messages()); n != 0 {
+		t.Errorf("server accepted %d messages, want 0", n)
+	}
+}
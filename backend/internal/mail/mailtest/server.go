@@ -0,0 +1,161 @@
+// Package mailtest runs a local SMTP server for tests of code that sends
+// mail.
+package mailtest
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message is one mail the server accepted.
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Server is a minimal SMTP server on a loopback port. It speaks just enough
+// of RFC 5321 for net/smtp: no TLS, and AUTH PLAIN accepts any credentials.
+type Server struct {
+	Host string
+	Port string
+
+	listener   net.Listener
+	mu         sync.Mutex
+	rejectRcpt bool
+	messages   []Message
+	auths      []string
+	wg         sync.WaitGroup
+}
+
+// NewServer starts a server that stops when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	s := &Server{Host: host, Port: port, listener: l}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(func() {
+		l.Close()
+		s.wg.Wait()
+	})
+	return s
+}
+
+// RejectRecipients makes the server refuse every recipient from now on.
+func (s *Server) RejectRecipients() {
+	s.mu.Lock()
+	s.rejectRcpt = true
+	s.mu.Unlock()
+}
+
+// Messages returns the mail accepted so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Auths returns the AUTH PLAIN responses received so far.
+func (s *Server) Auths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.auths...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.session(conn)
+		}()
+	}
+}
+
+func (s *Server) session(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP mailtest")
+
+	var msg Message
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(verb, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(verb, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(verb, "AUTH PLAIN"):
+			s.mu.Lock()
+			s.auths = append(s.auths, strings.TrimSpace(line[len("AUTH PLAIN"):]))
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case strings.HasPrefix(verb, "MAIL FROM:"):
+			msg = Message{From: trimPath(line[len("MAIL FROM:"):])}
+			reply("250 ok")
+		case strings.HasPrefix(verb, "RCPT TO:"):
+			s.mu.Lock()
+			reject := s.rejectRcpt
+			s.mu.Unlock()
+			if reject {
+				reply("550 no such user")
+				continue
+			}
+			msg.To = append(msg.To, trimPath(line[len("RCPT TO:"):]))
+			reply("250 ok")
+		case verb == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 queued")
+		case verb == "RSET", verb == "NOOP":
+			reply("250 ok")
+		case verb == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func trimPath(v string) string {
+	v = strings.TrimSpace(v)
+	if i := strings.IndexByte(v, ' '); i >= 0 {
+		v = v[:i]
+	}
+	return strings.Trim(v, "<>")
+}
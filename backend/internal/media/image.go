@@ -0,0 +1,96 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+const (
+	// MaxPixels guards against images that are small on disk but huge
+	// once decoded.
+	MaxPixels = 40_000_000
+	// ThumbnailSize bounds the longer side of a thumbnail.
+	ThumbnailSize = 320
+)
+
+// Keys for an image's files in storage.
+func OriginalKey(imageID string) string  { return "items/" + imageID + "/original" }
+func ThumbnailKey(imageID string) string { return "items/" + imageID + "/thumb.jpg" }
+
+// Image is a decoded upload.
+type Image struct {
+	Image       image.Image
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Decode checks that data is a JPEG, PNG or GIF of a sane size and decodes it.
+func Decode(data []byte) (Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, errors.New("file is not a JPEG, PNG or GIF image")
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return Image{}, errors.New("image dimensions are too large")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, errors.New("image is damaged")
+	}
+	return Image{Image: img, ContentType: "image/" + format, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Thumbnail scales img so its longer side is at most size, averaging the
+// source pixels under each target pixel, and encodes it as JPEG.
+func Thumbnail(img image.Image, size int) ([]byte, error) {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > size || sh > size {
+		if sw >= sh {
+			dw, dh = size, max(1, sh*size/sw)
+		} else {
+			dw, dh = max(1, sw*size/sh), size
+		}
+	}
+
+	// Work on RGBA pixels; draw.Draw has fast paths for the common decoders
+	src := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(src, src.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					bl += uint64(row[i+2])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(bl / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 82}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,30 @@
+-- Customer email on bills and a delivery log for emailed invoices
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS customer_email TEXT;
+
+CREATE TABLE IF NOT EXISTS bill_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bill_id UUID NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
+    recipient TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+    requested_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_bill_delivery_status CHECK (status IN ('pending', 'sending', 'retrying', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_deliveries_bill_id ON bill_deliveries (bill_id);
+CREATE INDEX IF NOT EXISTS idx_bill_deliveries_retry ON bill_deliveries (next_attempt_at) WHERE status IN ('retrying', 'sending');
+
+CREATE TABLE IF NOT EXISTS bill_delivery_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL REFERENCES bill_deliveries(id) ON DELETE CASCADE,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    succeeded BOOLEAN NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_delivery_attempts_delivery_id ON bill_delivery_attempts (delivery_id);
@@ -0,0 +1,13 @@
+-- Revocable, time-limited public share links for bills
+CREATE TABLE IF NOT EXISTS bill_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    bill_id UUID NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_by TEXT,
+    last_viewed_at TIMESTAMPTZ,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_shares_bill_id ON bill_shares (bill_id);
@@ -0,0 +1,21 @@
+-- Trigram search over item_id, name and arabic_name with Arabic normalization.
+-- normalize_search must stay in step with store.NormalizeSearch, which
+-- normalizes the query side.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE OR REPLACE FUNCTION normalize_search(input TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT btrim(regexp_replace(
+        regexp_replace(
+            translate(lower(coalesce(input, '')),
+                'أإآٱةىیکؤئ٠١٢٣٤٥٦٧٨٩٫',
+                'ااااهييكوي0123456789.'),
+            '[\u064B-\u065F\u0670\u0640]', '', 'g'),
+        '\s+', ' ', 'g'))
+$$;
+
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS search_text TEXT
+    GENERATED ALWAYS AS (normalize_search(item_id || ' ' || name || ' ' || coalesce(arabic_name, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_items_search_text_trgm ON items USING gin (search_text gin_trgm_ops);
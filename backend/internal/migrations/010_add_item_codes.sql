@@ -0,0 +1,14 @@
+-- Barcodes (EAN-13, Code 128) and supplier SKUs, unique across active items
+CREATE TABLE IF NOT EXISTS item_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    code TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    supplier TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_item_code_kind CHECK (kind IN ('ean13', 'code128', 'sku'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_codes_item_id ON item_codes (item_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_codes_active_code ON item_codes (code) WHERE active;
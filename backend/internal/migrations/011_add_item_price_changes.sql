@@ -0,0 +1,14 @@
+-- One row per changed price field, written alongside the item update
+CREATE TABLE IF NOT EXISTS item_price_changes (
+    id BIGSERIAL PRIMARY KEY,
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    field TEXT NOT NULL,
+    old_value NUMERIC(12, 3),
+    new_value NUMERIC(12, 3),
+    changed_by TEXT,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_item_price_change_field CHECK (field IN ('buyingPrice', 'sellingPrice', 'purchasePercentage', 'sellPercentage'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_price_changes_item_id ON item_price_changes (item_id, changed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_item_price_changes_changed_at ON item_price_changes (changed_at);
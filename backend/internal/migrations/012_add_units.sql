@@ -0,0 +1,48 @@
+-- Units of measure. items.unit is the item's base unit, which stock is kept
+-- in; item_units lists the other units it can be sold in and how many base
+-- units each one holds.
+CREATE TABLE IF NOT EXISTS units (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    arabic_name TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO units (code, name, arabic_name) VALUES
+    ('pcs', 'Piece', 'قطعة'),
+    ('m', 'Metre', 'متر'),
+    ('roll', 'Roll', 'لفة'),
+    ('drum', 'Drum', 'بكرة'),
+    ('box', 'Box', 'علبة'),
+    ('pack', 'Pack', 'باكيت')
+ON CONFLICT (code) DO NOTHING;
+
+-- Adopt whatever free-text units are already in use
+UPDATE items SET unit = 'pcs' WHERE unit IS NULL OR btrim(unit) = '';
+INSERT INTO units (code, name)
+SELECT DISTINCT unit, unit FROM items
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE items ALTER COLUMN unit SET NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_items_unit') THEN
+        ALTER TABLE items ADD CONSTRAINT fk_items_unit
+            FOREIGN KEY (unit) REFERENCES units(code) ON UPDATE CASCADE;
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS item_units (
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    unit TEXT NOT NULL REFERENCES units(code) ON UPDATE CASCADE,
+    factor NUMERIC(12, 4) NOT NULL,
+    PRIMARY KEY (item_id, unit),
+    CONSTRAINT check_item_unit_factor CHECK (factor > 0)
+);
+
+-- The unit a line was sold in and its size in base units at the time
+ALTER TABLE bill_items
+    ADD COLUMN IF NOT EXISTS unit TEXT,
+    ADD COLUMN IF NOT EXISTS unit_factor NUMERIC(12, 4) NOT NULL DEFAULT 1;
@@ -0,0 +1,48 @@
+-- Named price lists with per-item prices and a list-wide rule, and the
+-- customers they are assigned to
+CREATE TABLE IF NOT EXISTS price_lists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    arabic_name TEXT NOT NULL DEFAULT '',
+    -- Applied to selling_price for items without their own entry, e.g. -5
+    adjustment_percent NUMERIC(7, 3) NOT NULL DEFAULT 0,
+    -- Replaces sell_percentage for Wire/Box items without their own entry
+    sell_percentage NUMERIC(6, 3),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_price_list_adjustment CHECK (adjustment_percent > -100),
+    CONSTRAINT check_price_list_sell_percentage CHECK (sell_percentage BETWEEN 0 AND 100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_lists_name ON price_lists (lower(name));
+
+CREATE TABLE IF NOT EXISTS price_list_items (
+    price_list_id UUID NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    price NUMERIC(12, 3),
+    sell_percentage NUMERIC(6, 3),
+    PRIMARY KEY (price_list_id, item_id),
+    CONSTRAINT check_price_list_item_value CHECK (price IS NOT NULL OR sell_percentage IS NOT NULL),
+    CONSTRAINT check_price_list_item_price CHECK (price > 0),
+    CONSTRAINT check_price_list_item_sell_percentage CHECK (sell_percentage BETWEEN 0 AND 100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_item_id ON price_list_items (item_id);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    price_list_id UUID REFERENCES price_lists(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_name ON customers (lower(name));
+
+ALTER TABLE bills
+    ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS price_list_id UUID REFERENCES price_lists(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_bills_customer_id ON bills (customer_id);
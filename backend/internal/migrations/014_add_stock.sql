@@ -0,0 +1,36 @@
+-- Stock is kept in each item's base unit. stock_movements is the ledger and
+-- is never rewritten; stock_levels holds the running on-hand total and is
+-- updated in the same transaction as each movement.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE RESTRICT,
+    kind TEXT NOT NULL,
+    quantity NUMERIC(14, 4) NOT NULL,
+    bill_id UUID,
+    reference TEXT,
+    note TEXT,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_stock_movement_kind CHECK (kind IN ('sale', 'return', 'purchase', 'adjustment')),
+    CONSTRAINT check_stock_movement_quantity CHECK (quantity <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_item_id ON stock_movements (item_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_bill_id ON stock_movements (bill_id) WHERE bill_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION reject_stock_movement_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION reject_stock_movement_change();
+
+CREATE TABLE IF NOT EXISTS stock_levels (
+    item_id TEXT PRIMARY KEY REFERENCES items(item_id) ON DELETE CASCADE,
+    on_hand NUMERIC(14, 4) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
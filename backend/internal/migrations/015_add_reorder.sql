@@ -0,0 +1,15 @@
+-- Per-item reorder settings, in base units, and the low-stock list the
+-- daily reorder job writes
+ALTER TABLE stock_levels
+    ADD COLUMN IF NOT EXISTS reorder_point NUMERIC(14, 4) CHECK (reorder_point >= 0),
+    ADD COLUMN IF NOT EXISTS reorder_quantity NUMERIC(14, 4) CHECK (reorder_quantity > 0);
+
+CREATE TABLE IF NOT EXISTS reorder_suggestions (
+    item_id TEXT PRIMARY KEY REFERENCES items(item_id) ON DELETE CASCADE,
+    on_hand NUMERIC(14, 4) NOT NULL,
+    avg_daily_sales NUMERIC(14, 4) NOT NULL,
+    reorder_point NUMERIC(14, 4) NOT NULL,
+    suggested_quantity NUMERIC(14, 4) NOT NULL,
+    days_of_cover NUMERIC(10, 1),
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
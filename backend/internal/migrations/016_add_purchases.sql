@@ -0,0 +1,58 @@
+-- Suppliers and purchase invoices (goods received). A draft can be edited
+-- freely; posting it books the stock and, optionally, the new costs.
+CREATE TABLE IF NOT EXISTS suppliers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_suppliers_name ON suppliers (lower(name));
+
+CREATE TABLE IF NOT EXISTS purchase_invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    supplier_id UUID NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    invoice_number TEXT,
+    invoice_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    status TEXT NOT NULL DEFAULT 'draft',
+    -- Freight, customs and the like, spread over the lines by value
+    extra_costs NUMERIC(12, 3) NOT NULL DEFAULT 0,
+    subtotal NUMERIC(12, 3) NOT NULL DEFAULT 0,
+    total_amount NUMERIC(12, 3) NOT NULL DEFAULT 0,
+    update_prices BOOLEAN NOT NULL DEFAULT FALSE,
+    note TEXT,
+    created_by TEXT,
+    posted_by TEXT,
+    posted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_purchase_invoice_status CHECK (status IN ('draft', 'posted')),
+    CONSTRAINT check_purchase_invoice_extra_costs CHECK (extra_costs >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_invoices_supplier_id ON purchase_invoices (supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_invoices_invoice_date ON purchase_invoices (invoice_date);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_purchase_invoices_number
+    ON purchase_invoices (supplier_id, invoice_number) WHERE invoice_number IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS purchase_invoice_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_invoice_id UUID NOT NULL REFERENCES purchase_invoices(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE RESTRICT,
+    quantity NUMERIC(14, 4) NOT NULL,
+    unit TEXT NOT NULL,
+    unit_factor NUMERIC(12, 4) NOT NULL DEFAULT 1,
+    unit_cost NUMERIC(12, 3) NOT NULL,
+    -- Cost per base unit including the line's share of extra_costs, set on posting
+    landed_unit_cost NUMERIC(12, 4),
+    CONSTRAINT check_purchase_line_quantity CHECK (quantity > 0),
+    CONSTRAINT check_purchase_line_unit_cost CHECK (unit_cost >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_invoice_lines_invoice_id ON purchase_invoice_lines (purchase_invoice_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_invoice_lines_item_id ON purchase_invoice_lines (item_id);
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS purchase_invoice_id UUID;
@@ -0,0 +1,15 @@
+-- Item numbering: one Postgres sequence per prefix, item_id_seq_<prefix>,
+-- created on first use to carry on from the highest number seen, so an ID
+-- that has been used (even on a bill whose item is long gone) is not handed
+-- out again.
+
+-- Items created in a category use the nearest prefix up its tree
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS item_id_prefix TEXT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_categories_item_id_prefix') THEN
+        ALTER TABLE categories ADD CONSTRAINT check_categories_item_id_prefix
+            CHECK (item_id_prefix ~ '^[A-Za-z0-9]{1,20}$');
+    END IF;
+END $$;
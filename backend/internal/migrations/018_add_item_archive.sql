@@ -0,0 +1,6 @@
+-- Deleted items still named by bills, stock or purchases are archived when
+-- their restore window ends instead of being removed, so those records keep
+-- their unit and cost.
+ALTER TABLE items ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_items_archived_at ON items (archived_at);
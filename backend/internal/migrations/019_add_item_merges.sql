@@ -0,0 +1,35 @@
+-- Merging folds duplicate items into one. The old IDs keep resolving through
+-- item_redirects, and item_merges records what each merge moved.
+CREATE TABLE IF NOT EXISTS item_merges (
+    id BIGSERIAL PRIMARY KEY,
+    target_item_id TEXT NOT NULL,
+    source_item_id TEXT NOT NULL,
+    source_name TEXT NOT NULL,
+    bill_lines INT NOT NULL DEFAULT 0,
+    stock_movements INT NOT NULL DEFAULT 0,
+    price_changes INT NOT NULL DEFAULT 0,
+    merged_by TEXT,
+    merged_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_merges_target_item_id ON item_merges (target_item_id);
+
+CREATE TABLE IF NOT EXISTS item_redirects (
+    from_item_id TEXT PRIMARY KEY,
+    item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    merge_id BIGINT REFERENCES item_merges(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_redirects_item_id ON item_redirects (item_id);
+
+-- The ledger stays append-only, except that a merge may re-point movements
+-- to the surviving item.
+CREATE OR REPLACE FUNCTION reject_stock_movement_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND (to_jsonb(NEW) - 'item_id') = (to_jsonb(OLD) - 'item_id') THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
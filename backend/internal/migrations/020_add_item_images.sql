@@ -0,0 +1,17 @@
+-- Item photos. The files live in media storage under keys derived from the
+-- image id. When an item is purged its images lose their item and the
+-- cleanup job removes the files before dropping the rows.
+CREATE TABLE IF NOT EXISTS item_images (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id TEXT REFERENCES items(item_id) ON DELETE SET NULL ON UPDATE CASCADE,
+    content_type TEXT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_images_item_id ON item_images (item_id, position);
+CREATE INDEX IF NOT EXISTS idx_item_images_orphaned ON item_images (id) WHERE item_id IS NULL;
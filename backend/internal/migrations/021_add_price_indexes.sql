@@ -0,0 +1,28 @@
+-- Pricing indexes such as copper per tonne. A Wire/Box item linked to an
+-- index has its base price (buying_price) set to index value × factor each
+-- time a newer value is posted.
+CREATE TABLE IF NOT EXISTS price_indexes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    unit TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_indexes_name ON price_indexes (lower(name));
+
+CREATE TABLE IF NOT EXISTS price_index_values (
+    id BIGSERIAL PRIMARY KEY,
+    price_index_id UUID NOT NULL REFERENCES price_indexes(id) ON DELETE CASCADE,
+    effective_date DATE NOT NULL,
+    value NUMERIC(14, 4) NOT NULL,
+    posted_by TEXT,
+    posted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_price_index_value CHECK (value > 0),
+    CONSTRAINT uq_price_index_values_date UNIQUE (price_index_id, effective_date)
+);
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS price_index_id UUID REFERENCES price_indexes(id) ON DELETE RESTRICT;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS index_factor NUMERIC(18, 10);
+
+CREATE INDEX IF NOT EXISTS idx_items_price_index_id ON items (price_index_id) WHERE price_index_id IS NOT NULL;
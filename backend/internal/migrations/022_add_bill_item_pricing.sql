@@ -0,0 +1,3 @@
+-- The pricing rules that produced each bill line's price, in order, e.g.
+-- [{"rule": "base", "price": 1.2}, {"rule": "tier", "name": "Trade", "price": 1.1}]
+ALTER TABLE bill_items ADD COLUMN IF NOT EXISTS pricing JSONB;
@@ -0,0 +1,57 @@
+-- Better prices from a minimum quantity, on one item or on every item under
+-- a category
+CREATE TABLE IF NOT EXISTS quantity_breaks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id TEXT REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    -- In the item's base unit, e.g. 100 pcs or a 500 m drum
+    min_quantity NUMERIC(12, 3) NOT NULL,
+    -- Per base unit; only for item breaks
+    price NUMERIC(12, 3),
+    discount_percent NUMERIC(6, 3),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_quantity_break_scope CHECK ((item_id IS NULL) <> (category_id IS NULL)),
+    CONSTRAINT check_quantity_break_value CHECK ((price IS NULL) <> (discount_percent IS NULL)),
+    CONSTRAINT check_quantity_break_category_price CHECK (category_id IS NULL OR price IS NULL),
+    CONSTRAINT check_quantity_break_min_quantity CHECK (min_quantity > 0),
+    CONSTRAINT check_quantity_break_price CHECK (price > 0),
+    CONSTRAINT check_quantity_break_discount CHECK (discount_percent > 0 AND discount_percent < 100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quantity_breaks_item
+    ON quantity_breaks (item_id, min_quantity) WHERE item_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_quantity_breaks_category
+    ON quantity_breaks (category_id, min_quantity) WHERE category_id IS NOT NULL;
+
+-- Time-limited prices on one item or on every item under a category,
+-- running from starts_on to ends_on inclusive
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    arabic_name TEXT NOT NULL DEFAULT '',
+    item_id TEXT REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    price NUMERIC(12, 3),
+    discount_percent NUMERIC(6, 3),
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT check_promotion_scope CHECK ((item_id IS NULL) <> (category_id IS NULL)),
+    CONSTRAINT check_promotion_value CHECK ((price IS NULL) <> (discount_percent IS NULL)),
+    CONSTRAINT check_promotion_category_price CHECK (category_id IS NULL OR price IS NULL),
+    CONSTRAINT check_promotion_price CHECK (price > 0),
+    CONSTRAINT check_promotion_discount CHECK (discount_percent > 0 AND discount_percent < 100),
+    CONSTRAINT check_promotion_dates CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_item_id ON promotions (item_id);
+CREATE INDEX IF NOT EXISTS idx_promotions_category_id ON promotions (category_id);
+CREATE INDEX IF NOT EXISTS idx_promotions_ends_on ON promotions (ends_on);
+
+-- The promotion a bill line was sold under, kept by name in case it is
+-- deleted later
+ALTER TABLE bill_items
+    ADD COLUMN IF NOT EXISTS promotion_id UUID REFERENCES promotions(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS promotion_name TEXT;
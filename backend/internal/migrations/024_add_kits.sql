@@ -0,0 +1,31 @@
+-- Kits: items sold as a set of other items. A kit holds no stock of its
+-- own; selling one takes its components out of stock.
+ALTER TABLE items ADD COLUMN IF NOT EXISTS is_kit BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS kit_components (
+    kit_item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    component_item_id TEXT NOT NULL REFERENCES items(item_id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    -- Per kit, in unit; NULL means the component's base unit
+    quantity INTEGER NOT NULL,
+    unit TEXT,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (kit_item_id, component_item_id),
+    CONSTRAINT check_kit_component_self CHECK (kit_item_id <> component_item_id),
+    CONSTRAINT check_kit_component_quantity CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_kit_components_component ON kit_components (component_item_id);
+
+-- A kit line records the components it took out of stock, in base units per
+-- base unit of the kit, so later changes to the kit leave old bills alone.
+-- Lines from a kit billed as its components point back at the kit.
+CREATE TABLE IF NOT EXISTS bill_item_components (
+    bill_item_id UUID NOT NULL REFERENCES bill_items(id) ON DELETE CASCADE,
+    item_id TEXT NOT NULL,
+    quantity NUMERIC(14, 4) NOT NULL,
+    PRIMARY KEY (bill_item_id, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_item_components_item_id ON bill_item_components (item_id);
+
+ALTER TABLE bill_items ADD COLUMN IF NOT EXISTS kit_item_id TEXT;
@@ -0,0 +1,38 @@
+-- Minimum margin a hand-typed bill price must keep, on an item or on every
+-- item under a category. Normal items measure it on the selling price over
+-- the buying price, Wire/Box items on the base price over the purchase cost.
+ALTER TABLE items ADD COLUMN IF NOT EXISTS min_margin_percent NUMERIC(6, 3);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS min_margin_percent NUMERIC(6, 3);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_items_min_margin_percent') THEN
+        ALTER TABLE items ADD CONSTRAINT check_items_min_margin_percent
+            CHECK (min_margin_percent >= 0 AND min_margin_percent < 100);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'check_categories_min_margin_percent') THEN
+        ALTER TABLE categories ADD CONSTRAINT check_categories_min_margin_percent
+            CHECK (min_margin_percent >= 0 AND min_margin_percent < 100);
+    END IF;
+END $$;
+
+-- Every bill line priced by hand, with the price the catalog would have
+-- given. Kept when the bill is deleted.
+CREATE TABLE IF NOT EXISTS price_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    bill_id UUID REFERENCES bills(id) ON DELETE SET NULL,
+    item_id TEXT NOT NULL,
+    unit TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    -- Per unit
+    catalog_price NUMERIC(12, 3) NOT NULL,
+    override_price NUMERIC(12, 3) NOT NULL,
+    -- The lowest price the minimum margin allowed, if one applied
+    minimum_price NUMERIC(12, 3),
+    below_margin BOOLEAN NOT NULL DEFAULT false,
+    created_by TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_overrides_bill_id ON price_overrides (bill_id);
+CREATE INDEX IF NOT EXISTS idx_price_overrides_created_at ON price_overrides (created_at);
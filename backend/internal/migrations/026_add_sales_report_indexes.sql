@@ -0,0 +1,3 @@
+-- Sales reports scan bills by date and bill lines by item
+CREATE INDEX IF NOT EXISTS idx_bills_created_at ON bills (created_at);
+CREATE INDEX IF NOT EXISTS idx_bill_items_item_id ON bill_items (item_id);
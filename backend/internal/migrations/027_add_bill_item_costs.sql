@@ -0,0 +1,73 @@
+-- What a line cost at the time of sale, per line unit like unit_price, for
+-- profit reports. Wire/Box lines also keep the base price their margin is
+-- measured against.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'bill_items' AND column_name = 'unit_cost'
+    ) THEN
+        ALTER TABLE bill_items
+            ADD COLUMN unit_cost NUMERIC(12, 3),
+            ADD COLUMN base_price NUMERIC(12, 3);
+
+        -- Lines sold before costs were kept take each price as it stood at
+        -- the sale: the old value of the first logged change after it, else
+        -- the value now. A kit line costs its components, in base units per
+        -- base unit of the kit, never the kit's own buying price. Lines for
+        -- items since purged, and kit lines missing a component, stay unknown.
+        WITH parts AS (
+            SELECT bi.id AS bill_item_id, b.created_at, bi.item_id, 1::numeric AS quantity, false AS in_kit, 1 AS part_count
+            FROM bill_items bi
+            JOIN bills b ON b.id = bi.bill_id
+            JOIN items i ON i.item_id = bi.item_id
+            WHERE NOT i.is_kit
+              AND NOT EXISTS (SELECT 1 FROM bill_item_components bic WHERE bic.bill_item_id = bi.id)
+            UNION ALL
+            SELECT bi.id, b.created_at, bic.item_id, bic.quantity, true,
+                COUNT(*) OVER (PARTITION BY bi.id)::int
+            FROM bill_items bi
+            JOIN bills b ON b.id = bi.bill_id
+            JOIN bill_item_components bic ON bic.bill_item_id = bi.id
+        ),
+        part_prices AS (
+            SELECT p.bill_item_id, p.quantity, p.in_kit, p.part_count, i.is_wire_box,
+                CASE WHEN bp.id IS NULL THEN i.buying_price ELSE bp.old_value END AS buying_price,
+                CASE WHEN pp.id IS NULL THEN i.purchase_percentage ELSE pp.old_value END AS purchase_percentage
+            FROM parts p
+            JOIN items i ON i.item_id = p.item_id
+            LEFT JOIN LATERAL (
+                SELECT c.id, c.old_value FROM item_price_changes c
+                WHERE c.item_id = p.item_id AND c.field = 'buyingPrice' AND c.changed_at > p.created_at
+                ORDER BY c.changed_at, c.id
+                LIMIT 1
+            ) bp ON true
+            LEFT JOIN LATERAL (
+                SELECT c.id, c.old_value FROM item_price_changes c
+                WHERE c.item_id = p.item_id AND c.field = 'purchasePercentage' AND c.changed_at > p.created_at
+                ORDER BY c.changed_at, c.id
+                LIMIT 1
+            ) pp ON true
+        ),
+        part_costs AS (
+            SELECT bill_item_id, part_count, in_kit, is_wire_box, buying_price,
+                quantity * CASE
+                    WHEN is_wire_box THEN buying_price * (1 - purchase_percentage / 100)
+                    ELSE buying_price
+                END AS cost
+            FROM part_prices
+        ),
+        line_costs AS (
+            SELECT bill_item_id,
+                CASE WHEN COUNT(cost) = MAX(part_count) THEN SUM(cost) END AS cost,
+                MAX(CASE WHEN is_wire_box AND NOT in_kit THEN buying_price END) AS base_price
+            FROM part_costs
+            GROUP BY bill_item_id
+        )
+        UPDATE bill_items bi SET
+            unit_cost = bi.unit_factor * lc.cost,
+            base_price = bi.unit_factor * lc.base_price
+        FROM line_costs lc
+        WHERE lc.bill_item_id = bi.id;
+    END IF;
+END $$;
@@ -0,0 +1,14 @@
+-- Branches (shops) a bill can be rung up at, for per-branch sales reports.
+-- Bills from before branches were kept have none.
+CREATE TABLE IF NOT EXISTS branches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_branches_name ON branches (lower(name));
+
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS branch_id UUID REFERENCES branches(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_bills_branch_id ON bills (branch_id, created_at);
@@ -23,6 +23,75 @@ var addWireBoxFieldsSQL string
 //go:embed 005_remove_bill_item_discount.sql
 var removeBillItemDiscountSQL string
 
+//go:embed 006_add_bill_deliveries.sql
+var addBillDeliveriesSQL string
+
+//go:embed 007_add_bill_shares.sql
+var addBillSharesSQL string
+
+//go:embed 008_add_item_search.sql
+var addItemSearchSQL string
+
+//go:embed 009_add_categories_brands.sql
+var addCategoriesBrandsSQL string
+
+//go:embed 010_add_item_codes.sql
+var addItemCodesSQL string
+
+//go:embed 011_add_item_price_changes.sql
+var addItemPriceChangesSQL string
+
+//go:embed 012_add_units.sql
+var addUnitsSQL string
+
+//go:embed 013_add_price_lists.sql
+var addPriceListsSQL string
+
+//go:embed 014_add_stock.sql
+var addStockSQL string
+
+//go:embed 015_add_reorder.sql
+var addReorderSQL string
+
+//go:embed 016_add_purchases.sql
+var addPurchasesSQL string
+
+//go:embed 017_add_item_id_sequences.sql
+var addItemIDSequencesSQL string
+
+//go:embed 018_add_item_archive.sql
+var addItemArchiveSQL string
+
+//go:embed 019_add_item_merges.sql
+var addItemMergesSQL string
+
+//go:embed 020_add_item_images.sql
+var addItemImagesSQL string
+
+//go:embed 021_add_price_indexes.sql
+var addPriceIndexesSQL string
+
+//go:embed 022_add_bill_item_pricing.sql
+var addBillItemPricingSQL string
+
+//go:embed 023_add_quantity_breaks_promotions.sql
+var addQuantityBreaksPromotionsSQL string
+
+//go:embed 024_add_kits.sql
+var addKitsSQL string
+
+//go:embed 025_add_margin_guard.sql
+var addMarginGuardSQL string
+
+//go:embed 026_add_sales_report_indexes.sql
+var addSalesReportIndexesSQL string
+
+//go:embed 027_add_bill_item_costs.sql
+var addBillItemCostsSQL string
+
+//go:embed 028_add_branches.sql
+var addBranchesSQL string
+
 func Run(ctx context.Context, pool *pgxpool.Pool) error {
 	log.Println("Running database migrations...")
 
@@ -46,6 +115,98 @@ func Run(ctx context.Context, pool *pgxpool.Pool) error {
 		return err
 	}
 
+	if _, err := pool.Exec(ctx, addBillDeliveriesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addBillSharesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemSearchSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addCategoriesBrandsSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemCodesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemPriceChangesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addUnitsSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addPriceListsSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addStockSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addReorderSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addPurchasesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemIDSequencesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemArchiveSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemMergesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addItemImagesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addPriceIndexesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addBillItemPricingSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addQuantityBreaksPromotionsSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addKitsSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addMarginGuardSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addSalesReportIndexesSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addBillItemCostsSQL); err != nil {
+		return err
+	}
+
+	if _, err := pool.Exec(ctx, addBranchesSQL); err != nil {
+		return err
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
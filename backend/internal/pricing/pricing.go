@@ -0,0 +1,247 @@
+// Package pricing works out what a sale line costs the customer. A line
+// starts from the catalog price and passes through a pipeline of rules,
+// each of which may change the price of one base unit and notes what it did
+// so the bill can show how the price came about.
+package pricing
+
+import "math"
+
+// Rule names recorded on priced lines
+const (
+	RuleBase          = "base"
+	RuleTier          = "tier"
+	RuleQuantityBreak = "quantityBreak"
+	RulePromotion     = "promotion"
+	// RuleOverride marks a price typed in by the user instead of computed
+	RuleOverride = "override"
+)
+
+// Item is what the rules need to know about a catalog item. For Wire/Box
+// items BasePrice is the reference price the percentages apply to; for
+// other items it is the buying price.
+type Item struct {
+	ID                 string
+	IsWireBox          bool
+	BasePrice          *float64
+	SellingPrice       float64
+	SellPercentage     *float64
+	PurchasePercentage *float64
+}
+
+// Cost is what one base unit of the item costs to buy, if known.
+func (i Item) Cost() (float64, bool) {
+	if i.BasePrice == nil {
+		return 0, false
+	}
+	if !i.IsWireBox {
+		return *i.BasePrice, true
+	}
+	if i.PurchasePercentage == nil {
+		return 0, false
+	}
+	return WireBoxCost(*i.BasePrice, *i.PurchasePercentage), true
+}
+
+// MinimumPrice is the lowest price per base unit that keeps minMarginPercent
+// over cost. For normal items the margin is a share of the selling price;
+// for Wire/Box items, like sell% and purchase%, a share of the base price.
+func MinimumPrice(i Item, minMarginPercent float64) (float64, bool) {
+	cost, ok := i.Cost()
+	if !ok || minMarginPercent >= 100 {
+		return 0, false
+	}
+	if i.IsWireBox {
+		return cost + *i.BasePrice*minMarginPercent/100, true
+	}
+	return cost / (1 - minMarginPercent/100), true
+}
+
+// Tier is the customer's price list as it applies to one item: the item's
+// own entry, if any, and the list-wide terms.
+type Tier struct {
+	Name              string
+	Price             *float64
+	SellPercentage    *float64
+	AdjustmentPercent float64
+	ListSellPercent   *float64
+}
+
+// Break is a better price from a minimum quantity in base units, either a
+// fixed price per base unit or a discount off the price so far.
+type Break struct {
+	MinQuantity     float64
+	Price           *float64
+	DiscountPercent *float64
+	// Source says where the break comes from, e.g. the item or a category
+	Source string
+}
+
+// Promotion is a time-limited price. Callers only pass promotions running
+// on the sale date.
+type Promotion struct {
+	ID              string
+	Name            string
+	Price           *float64
+	DiscountPercent *float64
+}
+
+// Applied records one rule that set the line's price.
+type Applied struct {
+	Rule string `json:"rule"`
+	// Name identifies the price list, break or promotion
+	Name string `json:"name,omitempty"`
+	// Price is of one base unit after the rule
+	Price float64 `json:"price"`
+}
+
+// Line is a sale line on its way through the pipeline. Quantity is in base
+// units; Price is the running price of one base unit.
+type Line struct {
+	Item       Item
+	Quantity   float64
+	Tier       *Tier
+	Breaks     []Break
+	Promotions []Promotion
+
+	Price   float64
+	Applied []Applied
+	// Promotion is the promotion that set the final price, if any
+	Promotion *Promotion
+}
+
+func (l *Line) set(rule, name string, price float64) {
+	l.Price = price
+	l.Applied = append(l.Applied, Applied{Rule: rule, Name: name, Price: RoundFils(price)})
+}
+
+// Rule adjusts a line's price.
+type Rule func(l *Line)
+
+// Pipeline runs rules in order.
+type Pipeline []Rule
+
+// Default prices from the catalog, then the customer's tier, then quantity
+// breaks and promotions where they beat the price so far.
+var Default = Pipeline{BasePrice, CustomerTier, QuantityBreaks, Promotions}
+
+// Price runs the line through the pipeline and returns the price of one
+// base unit.
+func (p Pipeline) Price(l *Line) float64 {
+	l.Price = 0
+	l.Applied = nil
+	l.Promotion = nil
+	for _, rule := range p {
+		rule(l)
+	}
+	return l.Price
+}
+
+// BasePrice starts from the catalog: the selling price, or for Wire/Box
+// items the base price less sell%.
+func BasePrice(l *Line) {
+	price := l.Item.SellingPrice
+	if l.Item.IsWireBox && l.Item.BasePrice != nil && l.Item.SellPercentage != nil {
+		price = WireBoxSellingPrice(*l.Item.BasePrice, *l.Item.SellPercentage)
+	}
+	l.set(RuleBase, "", price)
+}
+
+// CustomerTier applies the customer's price list. In order: the item's own
+// price on the list, then for Wire/Box items a sell percentage from the
+// entry or the list, then the list's percentage adjustment.
+func CustomerTier(l *Line) {
+	t := l.Tier
+	if t == nil {
+		return
+	}
+	if t.Price != nil {
+		l.set(RuleTier, t.Name, *t.Price)
+		return
+	}
+	if l.Item.IsWireBox && l.Item.BasePrice != nil {
+		sell := t.SellPercentage
+		if sell == nil {
+			sell = t.ListSellPercent
+		}
+		if sell != nil {
+			l.set(RuleTier, t.Name, WireBoxSellingPrice(*l.Item.BasePrice, *sell))
+			return
+		}
+	}
+	if t.AdjustmentPercent != 0 {
+		l.set(RuleTier, t.Name, l.Price*(1+t.AdjustmentPercent/100))
+	}
+}
+
+// QuantityBreaks applies the break with the highest minimum the line
+// reaches, if it lowers the price.
+func QuantityBreaks(l *Line) {
+	var best *Break
+	for i := range l.Breaks {
+		b := &l.Breaks[i]
+		if l.Quantity >= b.MinQuantity && (best == nil || b.MinQuantity > best.MinQuantity) {
+			best = b
+		}
+	}
+	if best == nil {
+		return
+	}
+	if price := reduced(l.Price, best.Price, best.DiscountPercent); price < l.Price {
+		l.set(RuleQuantityBreak, best.Source, price)
+	}
+}
+
+// Promotions applies the running promotion that gives the lowest price, if
+// it beats the price so far.
+func Promotions(l *Line) {
+	var best *Promotion
+	bestPrice := l.Price
+	for i := range l.Promotions {
+		p := &l.Promotions[i]
+		if price := reduced(l.Price, p.Price, p.DiscountPercent); price < bestPrice {
+			best, bestPrice = p, price
+		}
+	}
+	if best != nil {
+		l.set(RulePromotion, best.Name, bestPrice)
+		l.Promotion = best
+	}
+}
+
+// reduced is a fixed price if given, else price less discount%.
+func reduced(price float64, fixed, discountPercent *float64) float64 {
+	switch {
+	case fixed != nil:
+		return *fixed
+	case discountPercent != nil:
+		return price * (1 - *discountPercent/100)
+	}
+	return price
+}
+
+// WireBoxSellingPrice is what a Wire/Box item sells for: base × (1 - sell%).
+// With a base of 1.000 KWD and 8% that is 0.920 KWD.
+func WireBoxSellingPrice(base, sellPercent float64) float64 {
+	return base * (1 - sellPercent/100)
+}
+
+// WireBoxCost is what a Wire/Box item costs to buy: base × (1 - purchase%).
+// With a base of 1.000 KWD and 9% that is 0.910 KWD, leaving 0.010 KWD
+// (1% of base) profit at 8% sell.
+func WireBoxCost(base, purchasePercent float64) float64 {
+	return base * (1 - purchasePercent/100)
+}
+
+// WireBoxBase works the base price back from a purchase cost. It returns
+// false when purchase% is 100 and any base would do.
+func WireBoxBase(cost, purchasePercent float64) (float64, bool) {
+	if purchasePercent >= 100 {
+		return 0, false
+	}
+	return cost / (1 - purchasePercent/100), true
+}
+
+// RoundFils rounds an amount to the fils, KWD's smallest unit.
+func RoundFils(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
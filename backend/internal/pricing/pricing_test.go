@@ -0,0 +1,223 @@
+package pricing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestDefaultPipeline(t *testing.T) {
+	item := Item{ID: "ITEM001", BasePrice: ptr(6), SellingPrice: 10}
+	wireBox := Item{ID: "WIRE001", IsWireBox: true, BasePrice: ptr(1), SellingPrice: 0.92, SellPercentage: ptr(8), PurchasePercentage: ptr(9)}
+	breaks := []Break{
+		{MinQuantity: 5, Price: ptr(9.5), Source: "item"},
+		{MinQuantity: 10, DiscountPercent: ptr(15), Source: "category"},
+	}
+
+	tests := []struct {
+		name      string
+		line      Line
+		want      float64
+		rules     []string
+		promotion string
+	}{
+		{
+			name:  "catalog price",
+			line:  Line{Item: item, Quantity: 1},
+			want:  10,
+			rules: []string{RuleBase},
+		},
+		{
+			name:  "Wire/Box base less sell%",
+			line:  Line{Item: wireBox, Quantity: 1},
+			want:  0.92,
+			rules: []string{RuleBase},
+		},
+		{
+			name:  "tier price",
+			line:  Line{Item: item, Quantity: 1, Tier: &Tier{Name: "Trade", Price: ptr(9)}},
+			want:  9,
+			rules: []string{RuleBase, RuleTier},
+		},
+		{
+			name:  "tier adjustment",
+			line:  Line{Item: item, Quantity: 1, Tier: &Tier{Name: "Trade", AdjustmentPercent: -10}},
+			want:  9,
+			rules: []string{RuleBase, RuleTier},
+		},
+		{
+			name:  "tier sell% from the list for Wire/Box",
+			line:  Line{Item: wireBox, Quantity: 1, Tier: &Tier{Name: "Trade", ListSellPercent: ptr(10), AdjustmentPercent: 5}},
+			want:  0.9,
+			rules: []string{RuleBase, RuleTier},
+		},
+		{
+			name:  "tier sell% on the entry beats the list's",
+			line:  Line{Item: wireBox, Quantity: 1, Tier: &Tier{Name: "Trade", SellPercentage: ptr(8.5), ListSellPercent: ptr(10)}},
+			want:  0.915,
+			rules: []string{RuleBase, RuleTier},
+		},
+		{
+			name:  "break not reached",
+			line:  Line{Item: item, Quantity: 4, Breaks: breaks},
+			want:  10,
+			rules: []string{RuleBase},
+		},
+		{
+			name:  "highest break reached",
+			line:  Line{Item: item, Quantity: 10, Breaks: breaks},
+			want:  8.5,
+			rules: []string{RuleBase, RuleQuantityBreak},
+		},
+		{
+			name:  "break discounts the tier price",
+			line:  Line{Item: item, Quantity: 10, Tier: &Tier{Name: "Trade", Price: ptr(9)}, Breaks: breaks},
+			want:  7.65,
+			rules: []string{RuleBase, RuleTier, RuleQuantityBreak},
+		},
+		{
+			name:  "break above the tier price is skipped",
+			line:  Line{Item: item, Quantity: 5, Tier: &Tier{Name: "Trade", Price: ptr(9)}, Breaks: breaks},
+			want:  9,
+			rules: []string{RuleBase, RuleTier},
+		},
+		{
+			name: "lowest promotion after the break",
+			line: Line{Item: item, Quantity: 10, Breaks: breaks, Promotions: []Promotion{
+				{ID: "p1", Name: "Fixed", Price: ptr(8)},
+				{ID: "p2", Name: "Tenth off", DiscountPercent: ptr(10)},
+			}},
+			want:      7.65,
+			rules:     []string{RuleBase, RuleQuantityBreak, RulePromotion},
+			promotion: "Tenth off",
+		},
+		{
+			name: "promotion above the price so far is skipped",
+			line: Line{Item: item, Quantity: 10, Breaks: breaks, Promotions: []Promotion{
+				{ID: "p1", Name: "Fixed", Price: ptr(9)},
+			}},
+			want:  8.5,
+			rules: []string{RuleBase, RuleQuantityBreak},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := tt.line
+			if got := RoundFils(Default.Price(&line)); got != tt.want {
+				t.Errorf("price = %v, want %v", got, tt.want)
+			}
+			rules := []string{}
+			for _, a := range line.Applied {
+				rules = append(rules, a.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("rules = %v, want %v", rules, tt.rules)
+			}
+			promotion := ""
+			if line.Promotion != nil {
+				promotion = line.Promotion.Name
+			}
+			if promotion != tt.promotion {
+				t.Errorf("promotion = %q, want %q", promotion, tt.promotion)
+			}
+		})
+	}
+}
+
+func TestPriceResetsLine(t *testing.T) {
+	line := Line{Item: Item{SellingPrice: 10}, Quantity: 1, Promotions: []Promotion{{Name: "Half", DiscountPercent: ptr(50)}}}
+	Default.Price(&line)
+	line.Promotions = nil
+	if got := Default.Price(&line); got != 10 || len(line.Applied) != 1 || line.Promotion != nil {
+		t.Errorf("second run = %v, applied %v, promotion %v", got, line.Applied, line.Promotion)
+	}
+}
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   Item
+		want   float64
+		wantOK bool
+	}{
+		{"buying price", Item{BasePrice: ptr(6)}, 6, true},
+		{"no buying price", Item{}, 0, false},
+		{"Wire/Box base less purchase%", Item{IsWireBox: true, BasePrice: ptr(1), PurchasePercentage: ptr(9)}, 0.91, true},
+		{"Wire/Box without purchase%", Item{IsWireBox: true, BasePrice: ptr(1)}, 0, false},
+		{"Wire/Box without base price", Item{IsWireBox: true, PurchasePercentage: ptr(9)}, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.item.Cost()
+		if RoundFils(got) != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: Cost() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMinimumPrice(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   Item
+		margin float64
+		want   float64
+		wantOK bool
+	}{
+		{"share of the selling price", Item{BasePrice: ptr(6)}, 25, 8, true},
+		{"no margin", Item{BasePrice: ptr(6)}, 0, 6, true},
+		{"unknown cost", Item{}, 25, 0, false},
+		{"margin of 100%", Item{BasePrice: ptr(6)}, 100, 0, false},
+		{"Wire/Box share of the base price", Item{IsWireBox: true, BasePrice: ptr(1), PurchasePercentage: ptr(9)}, 1, 0.92, true},
+		{"Wire/Box without purchase%", Item{IsWireBox: true, BasePrice: ptr(1)}, 1, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := MinimumPrice(tt.item, tt.margin)
+		if RoundFils(got) != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: MinimumPrice(%v) = %v, %v, want %v, %v", tt.name, tt.margin, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestWireBox(t *testing.T) {
+	if got := RoundFils(WireBoxSellingPrice(1, 8)); got != 0.92 {
+		t.Errorf("WireBoxSellingPrice(1, 8) = %v, want 0.92", got)
+	}
+	if got := RoundFils(WireBoxCost(1, 9)); got != 0.91 {
+		t.Errorf("WireBoxCost(1, 9) = %v, want 0.91", got)
+	}
+
+	tests := []struct {
+		cost, purchasePercent float64
+		want                  float64
+		wantOK                bool
+	}{
+		{0.91, 9, 1, true},
+		{2.5, 0, 2.5, true},
+		{0.91, 100, 0, false},
+		{0.91, 120, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := WireBoxBase(tt.cost, tt.purchasePercent)
+		if RoundFils(got) != tt.want || ok != tt.wantOK {
+			t.Errorf("WireBoxBase(%v, %v) = %v, %v, want %v, %v", tt.cost, tt.purchasePercent, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRoundFils(t *testing.T) {
+	tests := []struct {
+		v, want float64
+	}{
+		{0.92, 0.92},
+		{1.23449, 1.234},
+		{2.0006, 2.001},
+		{-1.2344, -1.234},
+		{7.649999999, 7.65},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		if got := RoundFils(tt.v); got != tt.want {
+			t.Errorf("RoundFils(%v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
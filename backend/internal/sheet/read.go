@@ -0,0 +1,82 @@
+// Package sheet reads and writes the tabular formats the catalog is
+// exchanged in: CSV and XLSX.
+package sheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New("format must be csv or xlsx")
+
+// DetectFormat picks a format from an explicit name, a file name or a
+// content type, in that order of preference.
+func DetectFormat(explicit, filename, contentType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(explicit)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	case "":
+	default:
+		return "", ErrUnknownFormat
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	switch {
+	case strings.Contains(contentType, "spreadsheetml"):
+		return FormatXLSX, nil
+	case strings.Contains(contentType, "csv"), strings.HasPrefix(contentType, "text/plain"):
+		return FormatCSV, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ReadAll returns every row of a CSV file or of the first worksheet of an
+// XLSX workbook. Rows may have differing lengths.
+func ReadAll(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(stripBOM(r))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case FormatXLSX:
+		book, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer book.Close()
+		sheets := book.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("workbook has no sheets")
+		}
+		return book.GetRows(sheets[0])
+	}
+	return nil, ErrUnknownFormat
+}
+
+// stripBOM drops the UTF-8 byte order mark Excel puts on CSV exports.
+func stripBOM(r io.Reader) io.Reader {
+	buf := make([]byte, 3)
+	n, err := io.ReadFull(r, buf)
+	if err != nil || string(buf[:n]) != "\xef\xbb\xbf" {
+		return io.MultiReader(bytes.NewReader(buf[:n]), r)
+	}
+	return r
+}
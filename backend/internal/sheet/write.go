@@ -0,0 +1,159 @@
+package sheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Writer emits rows one at a time. Close must be called to finish the file,
+// or Abort to give up on it; for XLSX nothing reaches the underlying writer
+// until Close.
+type Writer interface {
+	WriteRow(values []any) error
+	Close() error
+	Abort()
+}
+
+// ContentType returns the MIME type for a format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewWriter returns a streaming writer for format. Values may be strings,
+// numbers, bools, times or pointers to those; nil pointers become empty cells.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		// The byte order mark makes Excel open the file as UTF-8 so Arabic survives
+		if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		book := excelize.NewFile()
+		stream, err := book.NewStreamWriter("Sheet1")
+		if err != nil {
+			book.Close()
+			return nil, err
+		}
+		return &xlsxWriter{out: w, book: book, stream: stream}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatCell(v)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// Flush regularly so the client sees progress and memory stays flat
+	c.rows++
+	if c.rows%500 == 0 {
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Abort() {}
+
+// xlsxWriter relies on excelize's stream writer, which spills rows to a
+// temporary file rather than keeping the sheet in memory.
+type xlsxWriter struct {
+	out    io.Writer
+	book   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (x *xlsxWriter) WriteRow(values []any) error {
+	x.row++
+	cells := make([]any, len(values))
+	for i, v := range values {
+		cells[i] = cellValue(v)
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.book.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.book.Write(x.out)
+}
+
+func (x *xlsxWriter) Abort() {
+	x.book.Close()
+}
+
+// cellValue unwraps pointers so excelize stores numbers and dates natively.
+func cellValue(v any) any {
+	switch t := v.(type) {
+	case *string:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case *float64:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case *int:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case *time.Time:
+		if t == nil {
+			return nil
+		}
+		return t.Format(time.RFC3339)
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+	return v
+}
+
+func formatCell(v any) string {
+	switch t := cellValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(t)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const branchColumns = "id, name, created_at, updated_at"
+
+func scanBranch(row pgx.Row) (Branch, error) {
+	var b Branch
+	err := row.Scan(&b.ID, &b.Name, &b.CreatedAt, &b.UpdatedAt)
+	return b, err
+}
+
+func (s *Store) ListBranches(ctx context.Context) ([]Branch, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+branchColumns+" FROM branches ORDER BY lower(name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	branches := []Branch{}
+	for rows.Next() {
+		b, err := scanBranch(rows)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, b)
+	}
+	return branches, rows.Err()
+}
+
+func (s *Store) CreateBranch(ctx context.Context, input BranchInput) (Branch, error) {
+	b, err := scanBranch(s.db.QueryRow(ctx,
+		"INSERT INTO branches (name) VALUES ($1) RETURNING "+branchColumns,
+		strings.TrimSpace(input.Name),
+	))
+	return b, dbError(err)
+}
+
+func (s *Store) UpdateBranch(ctx context.Context, id string, input BranchInput) (Branch, error) {
+	b, err := scanBranch(s.db.QueryRow(ctx,
+		"UPDATE branches SET name=$2, updated_at=now() WHERE id=$1 RETURNING "+branchColumns,
+		id, strings.TrimSpace(input.Name),
+	))
+	return b, dbError(err)
+}
+
+// DeleteBranch removes a branch with no bills; otherwise it returns
+// ErrConflict.
+func (s *Store) DeleteBranch(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM branches WHERE id=$1", id)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return ErrConflict
+		}
+		return dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSalesSummaryByBranch(t *testing.T) {
+	s := testStore(t, Options{AllowNegativeStock: true})
+	ctx := context.Background()
+
+	item := testID("BR")
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price) VALUES ($1, $1, $1, 6, 10)", item)
+	north, err := s.CreateBranch(ctx, BranchInput{Name: testID("North ")})
+	if err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	south, err := s.CreateBranch(ctx, BranchInput{Name: testID("South ")})
+	if err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	bill := func(branchID *string, quantity int) Bill {
+		t.Helper()
+		b, err := s.CreateBill(ctx, BillCreate{BranchID: branchID, Items: []BillItemCreate{{ItemID: item, Quantity: quantity}}}, "clerk")
+		if err != nil {
+			t.Fatalf("CreateBill: %v", err)
+		}
+		return b
+	}
+	b := bill(&north.ID, 1)
+	bill(&north.ID, 2)
+	bill(&south.ID, 4)
+	bill(nil, 8)
+
+	summary, err := s.SalesSummary(ctx, SalesFilter{BranchID: north.ID}, GroupByDay)
+	if err != nil {
+		t.Fatalf("SalesSummary: %v", err)
+	}
+	if summary.Totals.BillCount != 2 || summary.Totals.Total != 30 {
+		t.Errorf("north totals = %+v, want 2 bills worth 30", summary.Totals)
+	}
+
+	// An update without a branch keeps the bill where it was rung up
+	updated, err := s.UpdateBill(ctx, b.ID, BillCreate{Items: []BillItemCreate{{ItemID: item, Quantity: 1}}}, "clerk")
+	if err != nil {
+		t.Fatalf("UpdateBill: %v", err)
+	}
+	if updated.BranchID == nil || *updated.BranchID != north.ID {
+		t.Errorf("branch after update = %v, want %s", updated.BranchID, north.ID)
+	}
+
+	if err := s.DeleteBranch(ctx, north.ID); err != ErrConflict {
+		t.Errorf("DeleteBranch with bills: got %v, want ErrConflict", err)
+	}
+	missing := "00000000-0000-0000-0000-000000000000"
+	if _, err := s.CreateBill(ctx, BillCreate{BranchID: &missing, Items: []BillItemCreate{{ItemID: item, Quantity: 1}}}, "clerk"); err == nil {
+		t.Error("CreateBill at an unknown branch succeeded")
+	}
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrCategoryCycle reports an attempt to move a category beneath itself.
+var ErrCategoryCycle = errors.New("category cannot be moved under itself or its descendants")
+
+// categorySubtreeSQL selects the ids of the category bound to placeholder n
+// and all of its descendants.
+func categorySubtreeSQL(n int) string {
+	return fmt.Sprintf(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = $%d
+		UNION ALL
+		SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+	) SELECT id FROM subtree`, n)
+}
+
+// categoryPathsSQL walks the tree from the roots, giving every category its
+// display path, depth and root.
+const categoryPathsSQL = `WITH RECURSIVE paths AS (
+	SELECT id, id AS root_id, name::text AS path, arabic_name::text AS arabic_path, 0 AS depth
+	FROM categories WHERE parent_id IS NULL
+	UNION ALL
+	SELECT c.id, p.root_id, p.path || ' > ' || c.name, p.arabic_path || ' > ' || c.arabic_name, p.depth + 1
+	FROM categories c JOIN paths p ON c.parent_id = p.id
+)`
+
+const categorySelectSQL = categoryPathsSQL + `
+	SELECT c.id, c.parent_id, c.name, c.arabic_name, p.path, p.arabic_path, p.depth,
+	       (SELECT COUNT(*) FROM items i WHERE i.category_id = c.id AND i.deleted_at IS NULL),
+	       c.item_id_prefix, c.min_margin_percent, c.created_at, c.updated_at
+	FROM categories c
+	JOIN paths p ON p.id = c.id`
+
+func scanCategory(row pgx.Row) (Category, error) {
+	var c Category
+	err := row.Scan(&c.ID, &c.ParentID, &c.Name, &c.ArabicName, &c.Path, &c.ArabicPath, &c.Depth, &c.ItemCount, &c.ItemIDPrefix, &c.MinMarginPercent, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+func (s *Store) ListCategories(ctx context.Context) ([]Category, error) {
+	rows, err := s.db.Query(ctx, categorySelectSQL+" ORDER BY p.path")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (s *Store) GetCategory(ctx context.Context, id string) (Category, error) {
+	c, err := scanCategory(s.db.QueryRow(ctx, categorySelectSQL+" WHERE c.id = $1", id))
+	if err != nil {
+		return c, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Store) CreateCategory(ctx context.Context, input CategoryInput) (Category, error) {
+	var id string
+	err := s.db.QueryRow(ctx,
+		"INSERT INTO categories (parent_id, name, arabic_name, item_id_prefix, min_margin_percent) VALUES ($1, $2, $3, NULLIF($4, ''), $5) RETURNING id",
+		input.ParentID, strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName), input.ItemIDPrefix, input.MinMarginPercent,
+	).Scan(&id)
+	if err != nil {
+		return Category{}, categoryWriteError(err)
+	}
+	return s.GetCategory(ctx, id)
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, id string, input CategoryInput) (Category, error) {
+	if input.ParentID != nil {
+		var cycle bool
+		if err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM ("+categorySubtreeSQL(1)+") sub WHERE sub.id = $2)", id, *input.ParentID).Scan(&cycle); err != nil {
+			return Category{}, err
+		}
+		if cycle {
+			return Category{}, ErrCategoryCycle
+		}
+	}
+
+	cmd, err := s.db.Exec(ctx, `
+		UPDATE categories SET parent_id=$2, name=$3, arabic_name=$4,
+		       item_id_prefix=CASE WHEN $5::text IS NULL THEN item_id_prefix ELSE NULLIF($5, '') END,
+		       min_margin_percent=COALESCE($6, min_margin_percent), updated_at=now()
+		WHERE id=$1
+	`, id, input.ParentID, strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName), input.ItemIDPrefix, input.MinMarginPercent,
+	)
+	if err != nil {
+		return Category{}, categoryWriteError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return Category{}, ErrNotFound
+	}
+	return s.GetCategory(ctx, id)
+}
+
+// SetCategoryMinMargin sets or, with nil, clears the minimum margin for
+// items in a category's branch without their own.
+func (s *Store) SetCategoryMinMargin(ctx context.Context, id string, percent *float64) (Category, error) {
+	cmd, err := s.db.Exec(ctx, "UPDATE categories SET min_margin_percent=$2, updated_at=now() WHERE id=$1", id, percent)
+	if err != nil {
+		return Category{}, dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return Category{}, ErrNotFound
+	}
+	return s.GetCategory(ctx, id)
+}
+
+// DeleteCategory removes an empty branch. Items in it become uncategorized;
+// categories with children are refused.
+func (s *Store) DeleteCategory(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM categories WHERE id=$1", id)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return ErrConflict
+		}
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func categoryWriteError(err error) error {
+	switch {
+	case isPgError(err, pgUniqueViolation):
+		return ErrConflict
+	case isPgError(err, pgForeignKeyViolation):
+		return ErrInvalidReference
+	}
+	return err
+}
+
+const brandSelectSQL = `SELECT b.id, b.name, b.arabic_name,
+	(SELECT COUNT(*) FROM items i WHERE i.brand_id = b.id AND i.deleted_at IS NULL),
+	b.created_at, b.updated_at
+	FROM brands b`
+
+func scanBrand(row pgx.Row) (Brand, error) {
+	var b Brand
+	err := row.Scan(&b.ID, &b.Name, &b.ArabicName, &b.ItemCount, &b.CreatedAt, &b.UpdatedAt)
+	return b, err
+}
+
+func (s *Store) ListBrands(ctx context.Context) ([]Brand, error) {
+	rows, err := s.db.Query(ctx, brandSelectSQL+" ORDER BY lower(b.name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	brands := []Brand{}
+	for rows.Next() {
+		b, err := scanBrand(rows)
+		if err != nil {
+			return nil, err
+		}
+		brands = append(brands, b)
+	}
+	return brands, rows.Err()
+}
+
+func (s *Store) GetBrand(ctx context.Context, id string) (Brand, error) {
+	b, err := scanBrand(s.db.QueryRow(ctx, brandSelectSQL+" WHERE b.id = $1", id))
+	if err != nil {
+		return b, ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *Store) CreateBrand(ctx context.Context, input BrandInput) (Brand, error) {
+	var id string
+	err := s.db.QueryRow(ctx,
+		"INSERT INTO brands (name, arabic_name) VALUES ($1, $2) RETURNING id",
+		strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName),
+	).Scan(&id)
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return Brand{}, ErrConflict
+		}
+		return Brand{}, err
+	}
+	return s.GetBrand(ctx, id)
+}
+
+func (s *Store) UpdateBrand(ctx context.Context, id string, input BrandInput) (Brand, error) {
+	cmd, err := s.db.Exec(ctx,
+		"UPDATE brands SET name=$2, arabic_name=$3, updated_at=now() WHERE id=$1",
+		id, strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName),
+	)
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return Brand{}, ErrConflict
+		}
+		return Brand{}, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return Brand{}, ErrNotFound
+	}
+	return s.GetBrand(ctx, id)
+}
+
+func (s *Store) DeleteBrand(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM brands WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// replaceItemCodes swaps the item's codes for codes inside tx. A code that is
+// already active on another item yields ErrConflict.
+func replaceItemCodes(ctx context.Context, tx pgx.Tx, itemID string, codes []ItemCode) ([]ItemCode, error) {
+	if _, err := tx.Exec(ctx, "DELETE FROM item_codes WHERE item_id=$1", itemID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(ctx, "INSERT INTO item_codes (item_id, code, kind, supplier) VALUES ($1, $2, $3, $4)", itemID, code.Code, code.Kind, code.Supplier); err != nil {
+			if isPgError(err, pgUniqueViolation) {
+				return nil, ErrConflict
+			}
+			return nil, err
+		}
+	}
+	return loadItemCodes(ctx, tx, itemID)
+}
+
+func loadItemCodes(ctx context.Context, q querier, itemID string) ([]ItemCode, error) {
+	rows, err := q.Query(ctx, "SELECT code, kind, supplier FROM item_codes WHERE item_id=$1 ORDER BY kind, code", itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []ItemCode{}
+	for rows.Next() {
+		var code ItemCode
+		if err := rows.Scan(&code.Code, &code.Kind, &code.Supplier); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+// attachItemCodes fills in Codes for a page of items with a single query.
+func (s *Store) attachItemCodes(ctx context.Context, items []Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]string, len(items))
+	index := make(map[string]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ItemID
+		index[items[i].ItemID] = i
+		items[i].Codes = []ItemCode{}
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT item_id, code, kind, supplier FROM item_codes WHERE item_id = ANY($1) ORDER BY kind, code", ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID string
+		var code ItemCode
+		if err := rows.Scan(&itemID, &code.Code, &code.Kind, &code.Supplier); err != nil {
+			return err
+		}
+		if i, ok := index[itemID]; ok {
+			items[i].Codes = append(items[i].Codes, code)
+		}
+	}
+	return rows.Err()
+}
+
+// GetItemByCode resolves a scanned barcode, a supplier SKU or a plain item ID
+// (including one merged into another item) to an active item.
+func (s *Store) GetItemByCode(ctx context.Context, code string) (Item, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT `+itemColumns+` FROM items
+		WHERE deleted_at IS NULL
+		  AND (item_id IN (SELECT item_id FROM item_codes WHERE code = $1 AND active) OR item_id = $1
+		       OR item_id IN (SELECT item_id FROM item_redirects WHERE from_item_id = $1))
+		ORDER BY item_id = $1 -- a barcode match wins over an item ID that looks the same
+		LIMIT 1
+	`, code)
+	item, err := scanItem(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return item, ErrNotFound
+		}
+		return item, err
+	}
+	if item.Codes, err = loadItemCodes(ctx, s.db, item.ItemID); err != nil {
+		return item, err
+	}
+	if item.Units, err = loadItemUnits(ctx, s.db, item.ItemID); err != nil {
+		return item, err
+	}
+	item.Images, err = loadItemImages(ctx, s.db, item.ItemID)
+	return item, err
+}
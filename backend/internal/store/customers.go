@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const customerColumns = "id, name, email, phone, price_list_id, created_at, updated_at"
+
+func scanCustomer(row pgx.Row) (Customer, error) {
+	var c Customer
+	err := row.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.PriceListID, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// ListCustomers returns customers ordered by name, optionally narrowed to
+// names containing query.
+func (s *Store) ListCustomers(ctx context.Context, query string) ([]Customer, error) {
+	rows, err := s.db.Query(ctx,
+		"SELECT "+customerColumns+" FROM customers WHERE $1 = '' OR name ILIKE '%' || $1 || '%' ORDER BY lower(name)",
+		likeEscaper.Replace(query),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := []Customer{}
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, rows.Err()
+}
+
+func (s *Store) GetCustomer(ctx context.Context, id string) (Customer, error) {
+	c, err := scanCustomer(s.db.QueryRow(ctx, "SELECT "+customerColumns+" FROM customers WHERE id=$1", id))
+	if err != nil {
+		return c, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *Store) CreateCustomer(ctx context.Context, input CustomerInput) (Customer, error) {
+	c, err := scanCustomer(s.db.QueryRow(ctx,
+		"INSERT INTO customers (name, email, phone, price_list_id) VALUES ($1, $2, $3, $4) RETURNING "+customerColumns,
+		strings.TrimSpace(input.Name), input.Email, input.Phone, input.PriceListID,
+	))
+	return c, dbError(err)
+}
+
+func (s *Store) UpdateCustomer(ctx context.Context, id string, input CustomerInput) (Customer, error) {
+	c, err := scanCustomer(s.db.QueryRow(ctx,
+		"UPDATE customers SET name=$2, email=$3, phone=$4, price_list_id=$5, updated_at=now() WHERE id=$1 RETURNING "+customerColumns,
+		id, strings.TrimSpace(input.Name), input.Email, input.Phone, input.PriceListID,
+	))
+	return c, dbError(err)
+}
+
+// DeleteCustomer removes a customer; their bills keep the name and email
+// they were issued with.
+func (s *Store) DeleteCustomer(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM customers WHERE id=$1", id)
+	if err != nil {
+		return dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// resolveBillCustomer fills the bill's customer name and email from a linked
+// customer where the bill leaves them out, checks the bill's branch, settles
+// which price list applies and returns it, or nil for catalog prices.
+func resolveBillCustomer(ctx context.Context, q querier, input *BillCreate) (*PriceList, error) {
+	if input.CustomerID != nil && *input.CustomerID != "" {
+		c, err := scanCustomer(q.QueryRow(ctx, "SELECT "+customerColumns+" FROM customers WHERE id=$1", *input.CustomerID))
+		if err != nil {
+			return nil, invalidf("customer not found")
+		}
+		if input.Customer == nil || strings.TrimSpace(*input.Customer) == "" {
+			input.Customer = &c.Name
+		}
+		if input.CustomerEmail == nil || strings.TrimSpace(*input.CustomerEmail) == "" {
+			input.CustomerEmail = c.Email
+		}
+		if input.PriceListID == nil || *input.PriceListID == "" {
+			input.PriceListID = c.PriceListID
+		}
+	} else {
+		input.CustomerID = nil
+	}
+
+	if input.BranchID != nil && *input.BranchID != "" {
+		var exists bool
+		if err := q.QueryRow(ctx, "SELECT true FROM branches WHERE id=$1", *input.BranchID).Scan(&exists); err != nil {
+			return nil, invalidf("branch not found")
+		}
+	} else {
+		input.BranchID = nil
+	}
+
+	if input.PriceListID == nil || *input.PriceListID == "" {
+		input.PriceListID = nil
+		return nil, nil
+	}
+	list, err := scanPriceList(q.QueryRow(ctx, priceListSelectSQL+" WHERE pl.id = $1", *input.PriceListID))
+	if err != nil {
+		return nil, invalidf("price list not found")
+	}
+	return &list, nil
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const deliveryColumns = "id, bill_id, recipient, status, attempts, last_error, next_attempt_at, sent_at, requested_by, created_at, updated_at"
+
+// A delivery left in "sending" this long is assumed to belong to a worker that died mid-send.
+const staleSendingWindow = 10 * time.Minute
+
+func scanDelivery(row pgx.Row) (BillDelivery, error) {
+	var d BillDelivery
+	err := row.Scan(&d.ID, &d.BillID, &d.Recipient, &d.Status, &d.Attempts, &d.LastError, &d.NextAttemptAt, &d.SentAt, &d.RequestedBy, &d.CreatedAt, &d.UpdatedAt)
+	return d, err
+}
+
+// CreateBillDelivery queues an invoice email for billID. The delivery starts in
+// the "sending" state so the retry job leaves it alone while the caller makes
+// the first attempt.
+func (s *Store) CreateBillDelivery(ctx context.Context, billID, recipient, requestedBy string) (BillDelivery, error) {
+	row := s.db.QueryRow(ctx,
+		"INSERT INTO bill_deliveries (bill_id, recipient, status, requested_by) SELECT id, $2, 'sending', NULLIF($3, '') FROM bills WHERE id=$1 RETURNING "+deliveryColumns,
+		billID, recipient, requestedBy,
+	)
+	d, err := scanDelivery(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return d, ErrNotFound
+	}
+	return d, err
+}
+
+// RecordDeliveryAttempt logs the outcome of one send attempt. A nil sendErr
+// marks the delivery sent. Otherwise the delivery is scheduled for retry at
+// nextAttempt, or marked failed when nextAttempt is nil.
+func (s *Store) RecordDeliveryAttempt(ctx context.Context, deliveryID string, sendErr error, nextAttempt *time.Time) (BillDelivery, error) {
+	var d BillDelivery
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return d, err
+	}
+	defer tx.Rollback(ctx)
+
+	var errText *string
+	if sendErr != nil {
+		msg := sendErr.Error()
+		errText = &msg
+	}
+
+	if _, err := tx.Exec(ctx, "INSERT INTO bill_delivery_attempts (delivery_id, succeeded, error) VALUES ($1, $2, $3)", deliveryID, sendErr == nil, errText); err != nil {
+		return d, err
+	}
+
+	status := "sent"
+	if sendErr != nil {
+		status = "failed"
+		if nextAttempt != nil {
+			status = "retrying"
+		}
+	}
+
+	row := tx.QueryRow(ctx, `
+		UPDATE bill_deliveries
+		SET status=$2,
+		    attempts=attempts + 1,
+		    last_error=$3,
+		    next_attempt_at=$4,
+		    sent_at=CASE WHEN $2 = 'sent' THEN now() ELSE sent_at END,
+		    updated_at=now()
+		WHERE id=$1
+		RETURNING `+deliveryColumns,
+		deliveryID, status, errText, nextAttempt,
+	)
+	d, err = scanDelivery(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return d, ErrNotFound
+		}
+		return d, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// ClaimDueDeliveries moves up to limit deliveries whose retry time has passed
+// into the "sending" state and returns them. Rows locked by another worker are
+// skipped.
+func (s *Store) ClaimDueDeliveries(ctx context.Context, limit int) ([]BillDelivery, error) {
+	rows, err := s.db.Query(ctx, `
+		UPDATE bill_deliveries
+		SET status='sending', updated_at=now()
+		WHERE id IN (
+			SELECT id FROM bill_deliveries
+			WHERE (status = 'retrying' AND next_attempt_at <= now())
+			   OR (status = 'sending' AND updated_at < $2)
+			ORDER BY next_attempt_at NULLS FIRST
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns,
+		limit, time.Now().Add(-staleSendingWindow),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []BillDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ListBillDeliveries returns every delivery for billID, newest first, with
+// the individual attempts attached.
+func (s *Store) ListBillDeliveries(ctx context.Context, billID string) ([]BillDelivery, error) {
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM bills WHERE id=$1)", billID).Scan(&exists); err != nil {
+		return nil, ErrNotFound
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT "+deliveryColumns+" FROM bill_deliveries WHERE bill_id=$1 ORDER BY created_at DESC", billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []BillDelivery{}
+	index := map[string]int{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		d.History = []DeliveryAttempt{}
+		index[d.ID] = len(deliveries)
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attemptRows, err := s.db.Query(ctx, `
+		SELECT a.delivery_id, a.id, a.attempted_at, a.succeeded, a.error
+		FROM bill_delivery_attempts a
+		JOIN bill_deliveries d ON d.id = a.delivery_id
+		WHERE d.bill_id=$1
+		ORDER BY a.attempted_at
+	`, billID)
+	if err != nil {
+		return nil, err
+	}
+	defer attemptRows.Close()
+
+	for attemptRows.Next() {
+		var deliveryID string
+		var attempt DeliveryAttempt
+		if err := attemptRows.Scan(&deliveryID, &attempt.ID, &attempt.AttemptedAt, &attempt.Succeeded, &attempt.Error); err != nil {
+			return nil, err
+		}
+		if i, ok := index[deliveryID]; ok {
+			deliveries[i].History = append(deliveries[i].History, attempt)
+		}
+	}
+	return deliveries, attemptRows.Err()
+}
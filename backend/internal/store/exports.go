@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// The Export* methods hand rows to fn as pgx reads them off the wire, so an
+// export never holds more than one row in memory. Returning an error from fn
+// stops the export.
+
+type ItemExportRow struct {
+	Item
+	Category string
+	Brand    string
+	Barcodes string
+	SKUs     string
+	Supplier string
+}
+
+type BillExportRow struct {
+	Bill
+	LineCount int
+}
+
+type BillLineExportRow struct {
+	BillID     string
+	BillDate   time.Time
+	Customer   *string
+	ItemID     string
+	ItemName   string
+	ArabicName string
+	Unit       string
+	Quantity   int
+	UnitPrice  float64
+}
+
+func (s *Store) ExportItems(ctx context.Context, filter ItemFilter, fn func(ItemExportRow) error) error {
+	conditions, score, args := searchClause(filter.Query, nil)
+	filterConditions, args := filter.where(args)
+	conditions = append(conditions, filterConditions...)
+
+	query := categoryPathsSQL + `
+		SELECT ` + itemColumns + `,
+		       COALESCE((SELECT path FROM paths WHERE paths.id = items.category_id), ''),
+		       COALESCE((SELECT name FROM brands WHERE brands.id = items.brand_id), ''),
+		       COALESCE((SELECT string_agg(code, ';' ORDER BY code) FROM item_codes c WHERE c.item_id = items.item_id AND c.kind <> 'sku'), ''),
+		       COALESCE((SELECT string_agg(code, ';' ORDER BY code) FROM item_codes c WHERE c.item_id = items.item_id AND c.kind = 'sku'), ''),
+		       COALESCE((SELECT min(supplier) FROM item_codes c WHERE c.item_id = items.item_id AND c.kind = 'sku'), '')
+		FROM items`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if score != "" {
+		query += " ORDER BY " + score + " DESC, name"
+	} else {
+		query += " ORDER BY name"
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ItemExportRow
+		dest := append(itemDest(&row.Item), &row.Category, &row.Brand, &row.Barcodes, &row.SKUs, &row.Supplier)
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) ExportBills(ctx context.Context, filter BillFilter, fn func(BillExportRow) error) error {
+	conditions, args := filter.where(nil)
+	query := `SELECT ` + billColumns + `,
+		(SELECT COUNT(*) FROM bill_items bi WHERE bi.bill_id = b.id)
+		FROM bills b`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY b.created_at DESC"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row BillExportRow
+		if err := rows.Scan(&row.ID, &row.Customer, &row.CustomerEmail, &row.CustomerID, &row.PriceListID, &row.BranchID, &row.TotalAmount, &row.CreatedAt, &row.UpdatedAt, &row.LineCount); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) ExportBillLines(ctx context.Context, filter BillFilter, fn func(BillLineExportRow) error) error {
+	conditions, args := filter.where(nil)
+	query := `SELECT b.id, b.created_at, b.customer_name, bi.item_id, bi.item_name,
+		       COALESCE(bi.item_name_ar, i.arabic_name, ''), COALESCE(bi.unit, i.unit, 'pcs'),
+		       bi.quantity, bi.unit_price
+		FROM bill_items bi
+		JOIN bills b ON b.id = bi.bill_id
+		LEFT JOIN items i ON i.item_id = bi.item_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY b.created_at DESC, bi.item_name"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row BillLineExportRow
+		if err := rows.Scan(&row.BillID, &row.BillDate, &row.Customer, &row.ItemID, &row.ItemName, &row.ArabicName, &row.Unit, &row.Quantity, &row.UnitPrice); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const itemImageColumns = "id, item_id, content_type, width, height, size_bytes, position, created_by, created_at"
+
+func scanItemImage(row pgx.Row) (ItemImage, error) {
+	var img ItemImage
+	err := row.Scan(&img.ID, &img.ItemID, &img.ContentType, &img.Width, &img.Height, &img.Size, &img.Position, &img.CreatedBy, &img.CreatedAt)
+	return img, err
+}
+
+func loadItemImages(ctx context.Context, q querier, itemID string) ([]ItemImage, error) {
+	rows, err := q.Query(ctx, "SELECT "+itemImageColumns+" FROM item_images WHERE item_id=$1 ORDER BY position, created_at", itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []ItemImage{}
+	for rows.Next() {
+		img, err := scanItemImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// attachItemImages fills in Images for a page of items with a single query.
+func (s *Store) attachItemImages(ctx context.Context, items []Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]string, len(items))
+	index := make(map[string]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ItemID
+		index[items[i].ItemID] = i
+		items[i].Images = []ItemImage{}
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT "+itemImageColumns+" FROM item_images WHERE item_id = ANY($1) ORDER BY position, created_at", ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		img, err := scanItemImage(rows)
+		if err != nil {
+			return err
+		}
+		if i, ok := index[img.ItemID]; ok {
+			items[i].Images = append(items[i].Images, img)
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) ListItemImages(ctx context.Context, itemID string) ([]ItemImage, error) {
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM items WHERE item_id=$1)", itemID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return loadItemImages(ctx, s.db, itemID)
+}
+
+// GetItemImage returns an image of the item; images of other items, or
+// deleted ones, are ErrNotFound.
+func (s *Store) GetItemImage(ctx context.Context, itemID, imageID string) (ItemImage, error) {
+	img, err := scanItemImage(s.db.QueryRow(ctx, "SELECT "+itemImageColumns+" FROM item_images WHERE id=$1 AND item_id=$2", imageID, itemID))
+	if err != nil {
+		return img, ErrNotFound
+	}
+	return img, nil
+}
+
+// CreateItemImage records a new image after the item's existing ones. The
+// caller stores the files under the returned ID.
+func (s *Store) CreateItemImage(ctx context.Context, img ItemImage, user string) (ItemImage, error) {
+	row := s.db.QueryRow(ctx, `
+		INSERT INTO item_images (item_id, content_type, width, height, size_bytes, position, created_by)
+		SELECT item_id, $2, $3, $4, $5,
+		       COALESCE((SELECT MAX(position) + 1 FROM item_images WHERE item_id = $1), 0),
+		       NULLIF($6, '')
+		FROM items WHERE item_id = $1 AND deleted_at IS NULL
+		RETURNING `+itemImageColumns,
+		img.ItemID, img.ContentType, img.Width, img.Height, img.Size, user,
+	)
+	created, err := scanItemImage(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return created, ErrNotFound
+	}
+	return created, err
+}
+
+// DeleteItemImage detaches an image from its item. Its files are removed
+// with the other orphaned images.
+func (s *Store) DeleteItemImage(ctx context.Context, itemID, imageID string) error {
+	cmd, err := s.db.Exec(ctx, "UPDATE item_images SET item_id=NULL WHERE id=$1 AND item_id=$2", imageID, itemID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// OrphanedImages lists images no longer attached to an item, because it
+// was purged or the image was deleted.
+func (s *Store) OrphanedImages(ctx context.Context) ([]string, error) {
+	rows, err := s.db.Query(ctx, "SELECT id FROM item_images WHERE item_id IS NULL LIMIT 500")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ForgetImages drops the records of orphaned images whose files are gone.
+func (s *Store) ForgetImages(ctx context.Context, ids []string) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM item_images WHERE id = ANY($1) AND item_id IS NULL", ids)
+	return err
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Import actions reported per row
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionError  = "error"
+)
+
+// ImportItems upserts rows in one transaction, each row inside its own
+// savepoint so a bad row is reported without hiding problems in later ones.
+// The transaction is committed only when commit is set and every row
+// succeeded; otherwise it is rolled back, which makes a dry run see exactly
+// what a real run would. A row without an item ID creates an item numbered
+// like CreateItem does. Price changes on updated items are recorded against
+// changedBy.
+func (s *Store) ImportItems(ctx context.Context, rows []ItemImportRow, commit bool, changedBy string) ([]ItemImportResult, bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialise with CreateItem, which takes the same lock
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(421987)"); err != nil {
+		return nil, false, err
+	}
+
+	results := make([]ItemImportResult, 0, len(rows))
+	ok := true
+	for _, row := range rows {
+		result := ItemImportResult{Row: row.Row, ItemID: row.Item.ItemID}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		if row.Item.ItemID == "" {
+			row.Item.ItemID, err = s.nextItemID(ctx, savepoint, row.Item.CategoryID)
+			result.ItemID = row.Item.ItemID
+		}
+		var action string
+		if err == nil {
+			action, err = upsertItem(ctx, savepoint, row.Item, changedBy)
+		}
+		if err == nil {
+			err = savepoint.Commit(ctx)
+		}
+		if err != nil {
+			_ = savepoint.Rollback(ctx)
+			ok = false
+			result.Action = ImportActionError
+			result.Error = importErrorMessage(err)
+		} else {
+			result.Action = action
+		}
+		results = append(results, result)
+	}
+
+	if commit && ok {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+	return results, ok, nil
+}
+
+func upsertItem(ctx context.Context, tx pgx.Tx, input ItemCreate, changedBy string) (string, error) {
+	unit := strings.TrimSpace(input.Unit)
+	if unit == "" {
+		unit = "pcs"
+	}
+
+	before, err := lockItemPrices(ctx, tx, input.ItemID)
+	existed := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	var after ItemPrices
+	err = tx.QueryRow(ctx, `
+		INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, category_id, brand_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (item_id) DO UPDATE SET
+			name=EXCLUDED.name,
+			arabic_name=EXCLUDED.arabic_name,
+			buying_price=EXCLUDED.buying_price,
+			selling_price=EXCLUDED.selling_price,
+			unit=EXCLUDED.unit,
+			is_wire_box=EXCLUDED.is_wire_box,
+			purchase_percentage=EXCLUDED.purchase_percentage,
+			sell_percentage=EXCLUDED.sell_percentage,
+			category_id=EXCLUDED.category_id,
+			brand_id=EXCLUDED.brand_id,
+			price_index_id=CASE WHEN EXCLUDED.is_wire_box THEN items.price_index_id END,
+			updated_at=now()
+		WHERE items.deleted_at IS NULL
+		RETURNING buying_price, selling_price, purchase_percentage, sell_percentage
+	`, input.ItemID, input.Name, input.ArabicName, input.BuyingPrice, input.SellingPrice, unit, input.IsWireBox, input.PurchasePercentage, input.SellPercentage, input.CategoryID, input.BrandID).Scan(&after.BuyingPrice, &after.SellingPrice, &after.PurchasePercentage, &after.SellPercentage)
+	if err != nil {
+		return "", err
+	}
+	if existed {
+		if err := recordPriceChanges(ctx, tx, input.ItemID, before, after, changedBy); err != nil {
+			return "", err
+		}
+	}
+
+	if input.Codes != nil {
+		if _, err := replaceItemCodes(ctx, tx, input.ItemID, input.Codes); err != nil {
+			return "", err
+		}
+	}
+
+	if existed {
+		return ImportActionUpdate, nil
+	}
+	return ImportActionCreate, nil
+}
+
+func importErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return "item is in the trash; restore it before importing over it"
+	case errors.Is(err, ErrConflict), isPgError(err, pgUniqueViolation):
+		return "barcode or SKU is already assigned to another item"
+	case isPgError(err, pgForeignKeyViolation):
+		return "categoryId, brandId or unit not found"
+	}
+	return err.Error()
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func loadKitComponents(ctx context.Context, q querier, kitID string) ([]KitComponent, error) {
+	rows, err := q.Query(ctx, `
+		SELECT kc.component_item_id, i.name, kc.quantity, COALESCE(kc.unit, '')
+		FROM kit_components kc
+		JOIN items i ON i.item_id = kc.component_item_id
+		WHERE kc.kit_item_id = $1
+		ORDER BY kc.position
+	`, kitID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := []KitComponent{}
+	for rows.Next() {
+		var c KitComponent
+		if err := rows.Scan(&c.ItemID, &c.Name, &c.Quantity, &c.Unit); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, rows.Err()
+}
+
+// GetKitComponents returns an item's components in order; an item that is
+// not a kit has none.
+func (s *Store) GetKitComponents(ctx context.Context, itemID string) ([]KitComponent, error) {
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM items WHERE item_id=$1)", itemID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return loadKitComponents(ctx, s.db, itemID)
+}
+
+// SetKitComponents replaces an item's components, making it a kit, or
+// turns it back into a plain item when components is empty. Components must
+// be live items that are not kits themselves, and a kit cannot be a
+// component of another kit or hold stock of its own. An item already on
+// bills, in the stock ledger or on purchase invoices stays a plain item, as
+// those lines would otherwise move stock of a kit.
+func (s *Store) SetKitComponents(ctx context.Context, itemID string, components []KitComponent) ([]KitComponent, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var onHand float64
+	var isKit bool
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(sl.on_hand, 0), i.is_kit
+		FROM items i
+		LEFT JOIN stock_levels sl ON sl.item_id = i.item_id
+		WHERE i.item_id=$1 AND i.deleted_at IS NULL
+		FOR UPDATE OF i
+	`, itemID).Scan(&onHand, &isKit)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(components) > 0 {
+		if roundQuantity(onHand) != 0 {
+			return nil, invalidf("item %s has %s on hand; adjust its stock to zero before making it a kit", itemID, formatQuantity(onHand))
+		}
+		var kitID string
+		err := tx.QueryRow(ctx, "SELECT kit_item_id FROM kit_components WHERE component_item_id=$1 LIMIT 1", itemID).Scan(&kitID)
+		if err == nil {
+			return nil, invalidf("item %s is a component of kit %s, so it cannot be a kit itself", itemID, kitID)
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if !isKit {
+			var used string
+			err := tx.QueryRow(ctx, `
+				SELECT CASE
+					WHEN EXISTS (SELECT 1 FROM bill_items WHERE item_id = $1) THEN 'bills'
+					WHEN EXISTS (SELECT 1 FROM stock_movements WHERE item_id = $1) THEN 'the stock ledger'
+					WHEN EXISTS (SELECT 1 FROM purchase_invoice_lines WHERE item_id = $1) THEN 'purchase invoices'
+					ELSE '' END
+			`, itemID).Scan(&used)
+			if err != nil {
+				return nil, err
+			}
+			if used != "" {
+				return nil, invalidf("item %s is already on %s; create a new item for the kit", itemID, used)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM kit_components WHERE kit_item_id=$1", itemID); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for i, c := range components {
+		if c.ItemID == itemID {
+			return nil, invalidf("a kit cannot contain itself")
+		}
+		if seen[c.ItemID] {
+			return nil, invalidf("item %s is listed twice", c.ItemID)
+		}
+		seen[c.ItemID] = true
+
+		var baseUnit string
+		var isKit bool
+		err := tx.QueryRow(ctx, "SELECT unit, is_kit FROM items WHERE item_id=$1 AND deleted_at IS NULL FOR SHARE", c.ItemID).Scan(&baseUnit, &isKit)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, invalidf("item %s not found", c.ItemID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isKit {
+			return nil, invalidf("item %s is a kit and cannot be a component", c.ItemID)
+		}
+		if _, err := saleUnitFactor(ctx, tx, c.ItemID, baseUnit, c.Unit); err != nil {
+			return nil, err
+		}
+		unit := &c.Unit
+		if c.Unit == "" || c.Unit == baseUnit {
+			unit = nil
+		}
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO kit_components (kit_item_id, component_item_id, quantity, unit, position) VALUES ($1, $2, $3, $4, $5)",
+			itemID, c.ItemID, c.Quantity, unit, i,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(ctx, "UPDATE items SET is_kit=$2, updated_at=now() WHERE item_id=$1", itemID, len(components) > 0); err != nil {
+		return nil, err
+	}
+
+	saved, err := loadKitComponents(ctx, tx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return saved, tx.Commit(ctx)
+}
+
+// kitPart is a kit component resolved for billing: count of unit per kit,
+// and the same in base units.
+type kitPart struct {
+	KitComponent
+	baseQuantity float64
+}
+
+// resolveKit loads a kit's components for a sale, refusing a kit with a
+// component that has since been deleted.
+func resolveKit(ctx context.Context, q querier, kitID string) ([]kitPart, error) {
+	rows, err := q.Query(ctx, `
+		SELECT kc.component_item_id, i.name, kc.quantity, COALESCE(kc.unit, ''), i.unit, i.deleted_at IS NOT NULL
+		FROM kit_components kc
+		JOIN items i ON i.item_id = kc.component_item_id
+		WHERE kc.kit_item_id = $1
+		ORDER BY kc.position
+	`, kitID)
+	if err != nil {
+		return nil, err
+	}
+	type row struct {
+		KitComponent
+		baseUnit string
+		deleted  bool
+	}
+	var found []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.ItemID, &r.Name, &r.Quantity, &r.Unit, &r.baseUnit, &r.deleted); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found = append(found, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, invalidf("kit %s has no components", kitID)
+	}
+
+	parts := make([]kitPart, 0, len(found))
+	for _, r := range found {
+		if r.deleted {
+			return nil, invalidf("component %s of kit %s has been deleted", r.ItemID, kitID)
+		}
+		factor, err := saleUnitFactor(ctx, q, r.ItemID, r.baseUnit, r.Unit)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, kitPart{KitComponent: r.KitComponent, baseQuantity: float64(r.Quantity) * factor})
+	}
+	return parts, nil
+}
+
+// kitCost is what one base unit of a kit costs from its components, or nil
+// when a component's cost is unknown.
+func kitCost(ctx context.Context, q querier, parts []kitPart) (*float64, error) {
+	var total float64
+	for _, part := range parts {
+		component, err := loadSaleItem(ctx, q, part.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		cost, ok := component.Cost()
+		if !ok {
+			return nil, nil
+		}
+		total += part.baseQuantity * cost
+	}
+	return &total, nil
+}
+
+// expandedQuantity is how many of a component's units a line of kits needs.
+// A kit sold in a unit holding a fraction of a kit may not come out whole.
+func expandedQuantity(kitQuantity int, kitFactor float64, part kitPart) (int, error) {
+	v := float64(kitQuantity) * kitFactor * float64(part.Quantity)
+	n := math.Round(v)
+	if math.Abs(v-n) > 1e-6 {
+		return 0, invalidf("the kit line needs %g of component %s, which is not a whole quantity", v, part.ItemID)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// An item sold as a plain item cannot become a kit: deleting or returning
+// that bill would put stock back into the kit itself.
+func TestSetKitComponentsRefusesSoldItem(t *testing.T) {
+	s := testStore(t, Options{AllowNegativeStock: true})
+	ctx := context.Background()
+
+	sold, part := testID("K"), testID("K")
+	for _, id := range []string{sold, part} {
+		exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price) VALUES ($1, $1, $1, 1)", id)
+	}
+	var billID string
+	if err := s.db.QueryRow(ctx, "INSERT INTO bills (total_amount) VALUES (1) RETURNING id").Scan(&billID); err != nil {
+		t.Fatal(err)
+	}
+	exec(t, s, "INSERT INTO bill_items (bill_id, item_id, item_name, quantity, unit_price) VALUES ($1, $2, $2, 1, 1)", billID, sold)
+
+	_, err := s.SetKitComponents(ctx, sold, []KitComponent{{ItemID: part, Quantity: 1}})
+	var invalidErr *InvalidError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("SetKitComponents: got %v, want an *InvalidError", err)
+	}
+}
@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"subahan-billing-backend/internal/pricing"
+)
+
+// buildBillItems resolves bill lines against the catalog inside tx and
+// returns them priced, along with the bill total. Lines without their own
+// unit price go through the pricing pipeline with the customer's tier from
+// list (nil for catalog prices), the item's quantity breaks and the
+// promotions running on today, a YYYY-MM-DD date. A line sold in a larger
+// unit than the item's base unit is priced in proportion. A kit becomes one
+// line carrying its components, or with ExpandKit one line per component.
+func buildBillItems(ctx context.Context, tx pgx.Tx, lines []BillItemCreate, list *PriceList, today string) ([]BillItem, float64, error) {
+	items := []BillItem{}
+	var total float64
+
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, 0, errors.New("quantity must be positive")
+		}
+
+		item, err := loadSaleItem(ctx, tx, line.ItemID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if item.isKit {
+			if item.parts, err = resolveKit(ctx, tx, item.ID); err != nil {
+				return nil, 0, err
+			}
+		} else if line.ExpandKit {
+			return nil, 0, invalidf("item %s is not a kit", item.ID)
+		}
+
+		if line.ExpandKit {
+			if line.UnitPrice != nil {
+				return nil, 0, invalidf("kit %s is billed as its components, which are priced on their own; leave out unitPrice", item.ID)
+			}
+			factor, err := saleUnitFactor(ctx, tx, item.ID, item.baseUnit, line.Unit)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, part := range item.parts {
+				quantity, err := expandedQuantity(line.Quantity, factor, part)
+				if err != nil {
+					return nil, 0, err
+				}
+				component, err := loadSaleItem(ctx, tx, part.ItemID)
+				if err != nil {
+					return nil, 0, err
+				}
+				billItem, err := priceBillItem(ctx, tx, list, component, BillItemCreate{ItemID: part.ItemID, Quantity: quantity, Unit: part.Unit}, today)
+				if err != nil {
+					return nil, 0, err
+				}
+				billItem.KitItemID = &item.ID
+				total += billItem.UnitPrice * float64(billItem.Quantity)
+				items = append(items, billItem)
+			}
+			continue
+		}
+
+		billItem, err := priceBillItem(ctx, tx, list, item, line, today)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, part := range item.parts {
+			billItem.Components = append(billItem.Components, BillItemComponent{ItemID: part.ItemID, Quantity: part.baseQuantity})
+		}
+		total += billItem.UnitPrice * float64(billItem.Quantity)
+		items = append(items, billItem)
+	}
+	return items, total, nil
+}
+
+// saleItem is what billing needs to know about a catalog item.
+type saleItem struct {
+	pricing.Item
+	name, arabicName, baseUnit string
+	isKit                      bool
+	// parts are a kit's components, once resolved
+	parts []kitPart
+}
+
+func loadSaleItem(ctx context.Context, q querier, itemID string) (saleItem, error) {
+	var item saleItem
+	row := q.QueryRow(ctx, "SELECT item_id, name, arabic_name, unit, is_wire_box, buying_price, selling_price, sell_percentage, purchase_percentage, is_kit FROM items WHERE item_id=$1 AND deleted_at IS NULL", itemID)
+	if err := row.Scan(&item.ID, &item.name, &item.arabicName, &item.baseUnit, &item.IsWireBox, &item.BasePrice, &item.SellingPrice, &item.SellPercentage, &item.PurchasePercentage, &item.isKit); err != nil {
+		return item, dbError(err)
+	}
+	return item, nil
+}
+
+// priceBillItem prices one line of item through the pricing pipeline. A
+// unitPrice on line that differs from the pipeline's price overrides it,
+// keeping what the pipeline would have charged and the item's minimum price
+// so the bill can be checked against the margin guard and the override
+// logged. A kit costs what its components do.
+func priceBillItem(ctx context.Context, q querier, list *PriceList, item saleItem, line BillItemCreate, today string) (BillItem, error) {
+	factor, err := saleUnitFactor(ctx, q, item.ID, item.baseUnit, line.Unit)
+	if err != nil {
+		return BillItem{}, err
+	}
+	billItem := BillItem{
+		ItemID:      item.ID,
+		ItemName:    item.name,
+		ArabicName:  item.arabicName,
+		Unit:        item.baseUnit,
+		UnitFactor:  factor,
+		Quantity:    line.Quantity,
+		BuyingPrice: item.BasePrice,
+	}
+	if line.Unit != "" {
+		billItem.Unit = line.Unit
+	}
+	// Costs and minimums below are per base unit, scaled to the line unit
+	costItem := item.Item
+	if item.isKit {
+		cost, err := kitCost(ctx, q, item.parts)
+		if err != nil {
+			return BillItem{}, err
+		}
+		costItem = pricing.Item{ID: item.ID, BasePrice: cost}
+	}
+	if cost, ok := costItem.Cost(); ok {
+		cost *= factor
+		billItem.UnitCost = &cost
+	}
+	if item.IsWireBox && item.BasePrice != nil {
+		base := *item.BasePrice * factor
+		billItem.basePrice = &base
+	}
+
+	priced, err := priceLine(ctx, q, list, item.Item, float64(line.Quantity)*factor, today)
+	if err != nil {
+		return BillItem{}, err
+	}
+	catalogPrice := roundFils(priced.Price * factor)
+	if line.UnitPrice != nil && roundFils(*line.UnitPrice) != catalogPrice {
+		billItem.UnitPrice = *line.UnitPrice
+		billItem.Pricing = append(priced.Applied, pricing.Applied{Rule: pricing.RuleOverride, Price: roundFils(*line.UnitPrice / factor)})
+		billItem.override = &lineOverride{catalogPrice: catalogPrice}
+		margin, err := minMarginPercent(ctx, q, item.ID)
+		if err != nil {
+			return BillItem{}, err
+		}
+		if margin != nil {
+			if minimum, ok := pricing.MinimumPrice(costItem, *margin); ok {
+				minimum = roundFils(minimum * factor)
+				billItem.override.minimumPrice = &minimum
+			}
+		}
+		return billItem, nil
+	}
+	billItem.UnitPrice = catalogPrice
+	billItem.Pricing = priced.Applied
+	if priced.Promotion != nil {
+		billItem.PromotionID, billItem.PromotionName = &priced.Promotion.ID, &priced.Promotion.Name
+	}
+	return billItem, nil
+}
+
+// priceLine runs an item through the default pricing pipeline for a sale of
+// quantity base units on today under list, which may be nil.
+func priceLine(ctx context.Context, q querier, list *PriceList, item pricing.Item, quantity float64, today string) (pricing.Line, error) {
+	line := pricing.Line{Item: item, Quantity: quantity}
+	var err error
+	if line.Breaks, line.Promotions, err = loadPricingRules(ctx, q, item.ID, today); err != nil {
+		return line, err
+	}
+	if list != nil {
+		tier := pricing.Tier{Name: list.Name, AdjustmentPercent: list.AdjustmentPercent, ListSellPercent: list.SellPercentage}
+		err = q.QueryRow(ctx,
+			"SELECT price, sell_percentage FROM price_list_items WHERE price_list_id=$1 AND item_id=$2",
+			list.ID, item.ID,
+		).Scan(&tier.Price, &tier.SellPercentage)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return line, err
+		}
+		line.Tier = &tier
+	}
+	pricing.Default.Price(&line)
+	return line, nil
+}
+
+func insertBillItems(ctx context.Context, tx pgx.Tx, billID string, items []BillItem) error {
+	for i := range items {
+		row := tx.QueryRow(ctx,
+			"INSERT INTO bill_items (bill_id, item_id, item_name, item_name_ar, unit, unit_factor, quantity, unit_price, unit_cost, base_price, pricing, promotion_id, promotion_name, kit_item_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id",
+			billID, items[i].ItemID, items[i].ItemName, items[i].ArabicName, items[i].Unit, items[i].UnitFactor, items[i].Quantity, items[i].UnitPrice, items[i].UnitCost, items[i].basePrice, items[i].Pricing, items[i].PromotionID, items[i].PromotionName, items[i].KitItemID,
+		)
+		if err := row.Scan(&items[i].ID); err != nil {
+			return err
+		}
+		for _, c := range items[i].Components {
+			if _, err := tx.Exec(ctx,
+				"INSERT INTO bill_item_components (bill_item_id, item_id, quantity) VALUES ($1, $2, $3)",
+				items[i].ID, c.ItemID, c.Quantity,
+			); err != nil {
+				return err
+			}
+		}
+		items[i].BillID = billID
+	}
+	return nil
+}
+
+// roundFils rounds an amount to the fils, KWD's smallest unit.
+func roundFils(v float64) float64 {
+	return pricing.RoundFils(v)
+}
@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MergeItems folds the source items into target: bill lines, stock
+// movements and on-hand quantity, price history, purchase lines, images,
+// codes, price list entries, pricing rules and kit memberships move to the
+// target, the sources are removed and their IDs redirect to the target.
+// Each source is logged in item_merges. Kits themselves cannot be merged,
+// nor items counted in different base units, whose quantities would not add
+// up.
+func (s *Store) MergeItems(ctx context.Context, targetID string, sourceIDs []string, user string) (ItemMerge, error) {
+	merge := ItemMerge{Sources: []ItemMergeSource{}}
+	if user != "" {
+		merge.MergedBy = &user
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return merge, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Same lock as CreateItem, so a merged ID cannot be created meanwhile
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(421987)"); err != nil {
+		return merge, err
+	}
+
+	var live, isKit bool
+	var unit string
+	err = tx.QueryRow(ctx, "SELECT deleted_at IS NULL, is_kit, unit FROM items WHERE item_id=$1 FOR UPDATE", targetID).Scan(&live, &isKit, &unit)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !live) {
+		return merge, ErrNotFound
+	}
+	if err != nil {
+		return merge, err
+	}
+	if isKit {
+		return merge, invalidf("item %s is a kit; kits cannot be merged", targetID)
+	}
+
+	// Sources are locked in ID order so two merges cannot deadlock
+	ids := append([]string(nil), sourceIDs...)
+	sort.Strings(ids)
+	for _, sourceID := range ids {
+		if sourceID == targetID {
+			return merge, invalidf("item %s cannot be merged into itself", sourceID)
+		}
+		var source ItemMergeSource
+		var sourceUnit string
+		source.ItemID = sourceID
+		err := tx.QueryRow(ctx, "SELECT name, is_kit, unit FROM items WHERE item_id=$1 FOR UPDATE", sourceID).Scan(&source.Name, &isKit, &sourceUnit)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return merge, invalidf("item %s not found", sourceID)
+		}
+		if err != nil {
+			return merge, err
+		}
+		if isKit {
+			return merge, invalidf("item %s is a kit; kits cannot be merged", sourceID)
+		}
+		if sourceUnit != unit {
+			return merge, invalidf("item %s is counted in %s, not %s like %s", sourceID, sourceUnit, unit, targetID)
+		}
+		if err := mergeItem(ctx, tx, targetID, &source, user); err != nil {
+			return merge, err
+		}
+		merge.Sources = append(merge.Sources, source)
+	}
+
+	if merge.Target, err = scanItem(tx.QueryRow(ctx, "SELECT "+itemColumns+" FROM items WHERE item_id=$1", targetID)); err != nil {
+		return merge, err
+	}
+	if merge.Target.Codes, err = loadItemCodes(ctx, tx, targetID); err != nil {
+		return merge, err
+	}
+	if merge.Target.Units, err = loadItemUnits(ctx, tx, targetID); err != nil {
+		return merge, err
+	}
+	if merge.Target.Images, err = loadItemImages(ctx, tx, targetID); err != nil {
+		return merge, err
+	}
+	merge.MergedAt = time.Now()
+	return merge, tx.Commit(ctx)
+}
+
+// mergeItem moves everything that names source over to target, then removes
+// source, leaving a redirect and a log entry behind.
+func mergeItem(ctx context.Context, tx pgx.Tx, targetID string, source *ItemMergeSource, user string) error {
+	cmd, err := tx.Exec(ctx, "UPDATE bill_items SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID)
+	if err != nil {
+		return err
+	}
+	source.BillLines = cmd.RowsAffected()
+
+	if cmd, err = tx.Exec(ctx, "UPDATE stock_movements SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+	source.StockMovements = cmd.RowsAffected()
+
+	var onHand float64
+	err = tx.QueryRow(ctx, "DELETE FROM stock_levels WHERE item_id=$1 RETURNING on_hand", source.ItemID).Scan(&onHand)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if onHand != 0 {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO stock_levels (item_id, on_hand) VALUES ($1, $2)
+			ON CONFLICT (item_id) DO UPDATE SET on_hand = stock_levels.on_hand + EXCLUDED.on_hand, updated_at = now()
+		`, targetID, onHand); err != nil {
+			return err
+		}
+	}
+
+	if cmd, err = tx.Exec(ctx, "UPDATE item_price_changes SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+	source.PriceChanges = cmd.RowsAffected()
+
+	if _, err := tx.Exec(ctx, "UPDATE purchase_invoice_lines SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE price_overrides SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+	// Photos follow the target's own
+	if _, err := tx.Exec(ctx, `
+		UPDATE item_images SET item_id=$2,
+		       position = position + COALESCE((SELECT MAX(position) + 1 FROM item_images WHERE item_id = $2), 0)
+		WHERE item_id=$1
+	`, source.ItemID, targetID); err != nil {
+		return err
+	}
+	// Codes and list prices the target lacks carry over; the rest go with
+	// the source
+	if _, err := tx.Exec(ctx,
+		"UPDATE item_codes SET item_id=$2 WHERE item_id=$1 AND code NOT IN (SELECT code FROM item_codes WHERE item_id=$2)",
+		source.ItemID, targetID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO price_list_items (price_list_id, item_id, price, sell_percentage)
+		SELECT price_list_id, $2, price, sell_percentage FROM price_list_items WHERE item_id=$1
+		ON CONFLICT (price_list_id, item_id) DO NOTHING
+	`, source.ItemID, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx,
+		"UPDATE quantity_breaks SET item_id=$2 WHERE item_id=$1 AND min_quantity NOT IN (SELECT min_quantity FROM quantity_breaks WHERE item_id=$2)",
+		source.ItemID, targetID,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE promotions SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+
+	// Kits using the source use the target instead. A kit with both would
+	// need their quantities combined, possibly across units, so that is left
+	// to the user.
+	var kitID string
+	err = tx.QueryRow(ctx,
+		"SELECT kit_item_id FROM kit_components WHERE component_item_id=$1 AND kit_item_id IN (SELECT kit_item_id FROM kit_components WHERE component_item_id=$2) LIMIT 1",
+		source.ItemID, targetID,
+	).Scan(&kitID)
+	if err == nil {
+		return invalidf("kit %s contains both %s and %s; change the kit first", kitID, source.ItemID, targetID)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE kit_components SET component_item_id=$2 WHERE component_item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO bill_item_components (bill_item_id, item_id, quantity)
+		SELECT bill_item_id, $2, quantity FROM bill_item_components WHERE item_id=$1
+		ON CONFLICT (bill_item_id, item_id) DO UPDATE SET quantity = bill_item_components.quantity + EXCLUDED.quantity
+	`, source.ItemID, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM bill_item_components WHERE item_id=$1", source.ItemID); err != nil {
+		return err
+	}
+
+	var mergeID int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO item_merges (target_item_id, source_item_id, source_name, bill_lines, stock_movements, price_changes, merged_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING id
+	`, targetID, source.ItemID, source.Name, source.BillLines, source.StockMovements, source.PriceChanges, user).Scan(&mergeID); err != nil {
+		return err
+	}
+
+	// IDs that already redirected to the source now lead to the target
+	if _, err := tx.Exec(ctx, "UPDATE item_redirects SET item_id=$2 WHERE item_id=$1", source.ItemID, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM items WHERE item_id=$1", source.ItemID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		"INSERT INTO item_redirects (from_item_id, item_id, merge_id) VALUES ($1, $2, $3)",
+		source.ItemID, targetID, mergeID,
+	)
+	return err
+}
+
+// redirectedItemID returns the item a merged ID now points to, or "" when
+// itemID was never merged.
+func redirectedItemID(ctx context.Context, q querier, itemID string) (string, error) {
+	var target string
+	err := q.QueryRow(ctx, "SELECT item_id FROM item_redirects WHERE from_item_id=$1", itemID).Scan(&target)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return target, err
+}
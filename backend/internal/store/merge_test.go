@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Metres of cable merged into a per-piece item would add their quantities
+// to its stock and bills as if they were pieces.
+func TestMergeItemsRefusesOtherUnit(t *testing.T) {
+	s := testStore(t, Options{})
+	ctx := context.Background()
+
+	target, source := testID("M"), testID("M")
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price, unit) VALUES ($1, $1, $1, 1, 'pcs')", target)
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price, unit) VALUES ($1, $1, $1, 1, 'm')", source)
+
+	_, err := s.MergeItems(ctx, target, []string{source}, "tester")
+	var invalidErr *InvalidError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("MergeItems: got %v, want an *InvalidError", err)
+	}
+	if _, err := s.GetItem(ctx, source); err != nil {
+		t.Errorf("source item after refused merge: %v", err)
+	}
+}
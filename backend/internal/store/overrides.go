@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MarginError reports a hand-typed price below an item's minimum margin,
+// from a user who may not override it.
+type MarginError struct {
+	ItemID       string
+	Price        float64
+	MinimumPrice float64
+}
+
+func (e *MarginError) Error() string {
+	return fmt.Sprintf("price %.3f for item %s is below its minimum margin; the lowest allowed is %.3f", e.Price, e.ItemID, e.MinimumPrice)
+}
+
+// lineOverride is what a bill line priced by hand replaced, per line unit.
+type lineOverride struct {
+	catalogPrice float64
+	minimumPrice *float64
+}
+
+func (o *lineOverride) belowMargin(price float64) bool {
+	return o.minimumPrice != nil && price < *o.minimumPrice
+}
+
+// minMarginPercent returns the minimum margin for an item: its own, else
+// that of its nearest category with one, else nil.
+func minMarginPercent(ctx context.Context, q querier, itemID string) (*float64, error) {
+	var margin *float64
+	err := q.QueryRow(ctx, itemCategoriesSQL+`
+		SELECT COALESCE(
+			(SELECT min_margin_percent FROM items WHERE item_id = $1),
+			(SELECT c.min_margin_percent FROM up JOIN categories c ON c.id = up.id
+			 WHERE c.min_margin_percent IS NOT NULL ORDER BY up.depth LIMIT 1))
+	`, itemID).Scan(&margin)
+	return margin, err
+}
+
+func (s *Store) mayOverrideMargin(user string) bool {
+	return user != "" && slices.Contains(s.opts.PriceOverrideUsers, user)
+}
+
+// recordOverrides logs a bill's hand-priced lines against user, refusing any
+// below its minimum margin unless user may override. A price already logged
+// on the bill, as when UpdateBill saves a line again unchanged, is neither
+// checked nor logged twice.
+func (s *Store) recordOverrides(ctx context.Context, tx pgx.Tx, billID string, items []BillItem, user string) error {
+	for _, item := range items {
+		o := item.override
+		if o == nil {
+			continue
+		}
+		var logged bool
+		if err := tx.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM price_overrides WHERE bill_id=$1 AND item_id=$2 AND unit=$3 AND override_price=$4)",
+			billID, item.ItemID, item.Unit, item.UnitPrice,
+		).Scan(&logged); err != nil {
+			return err
+		}
+		if logged {
+			continue
+		}
+		below := o.belowMargin(item.UnitPrice)
+		if below && !s.mayOverrideMargin(user) {
+			return &MarginError{ItemID: item.ItemID, Price: item.UnitPrice, MinimumPrice: *o.minimumPrice}
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO price_overrides (bill_id, item_id, unit, quantity, catalog_price, override_price, minimum_price, below_margin, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
+		`, billID, item.ItemID, item.Unit, item.Quantity, o.catalogPrice, item.UnitPrice, o.minimumPrice, below, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PriceOverrideFilter narrows the override log. To is exclusive.
+type PriceOverrideFilter struct {
+	From        *time.Time
+	To          *time.Time
+	ItemID      string
+	BelowMargin bool
+}
+
+func (f PriceOverrideFilter) where(args []any) ([]string, []any) {
+	conditions := []string{}
+	if f.From != nil {
+		args = append(args, *f.From)
+		conditions = append(conditions, fmt.Sprintf("o.created_at >= $%d", len(args)))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		conditions = append(conditions, fmt.Sprintf("o.created_at < $%d", len(args)))
+	}
+	if f.ItemID != "" {
+		args = append(args, f.ItemID)
+		conditions = append(conditions, fmt.Sprintf("o.item_id = $%d", len(args)))
+	}
+	if f.BelowMargin {
+		conditions = append(conditions, "o.below_margin")
+	}
+	return conditions, args
+}
+
+// ListPriceOverrides returns logged overrides, newest first.
+func (s *Store) ListPriceOverrides(ctx context.Context, filter PriceOverrideFilter, limit, offset int) ([]PriceOverride, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	conditions, args := filter.where(nil)
+	query := `
+		SELECT o.id, o.bill_id, o.item_id, COALESCE(i.name, ''), o.unit, o.quantity,
+		       o.catalog_price, o.override_price, o.minimum_price, o.below_margin, o.created_by, o.created_at
+		FROM price_overrides o
+		LEFT JOIN items i ON i.item_id = o.item_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY o.created_at DESC, o.id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []PriceOverride{}
+	for rows.Next() {
+		var o PriceOverride
+		if err := rows.Scan(&o.ID, &o.BillID, &o.ItemID, &o.ItemName, &o.Unit, &o.Quantity,
+			&o.CatalogPrice, &o.OverridePrice, &o.MinimumPrice, &o.BelowMargin, &o.CreatedBy, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// SetItemMinMargin sets or, with nil, clears an item's own minimum margin.
+func (s *Store) SetItemMinMargin(ctx context.Context, itemID string, percent *float64) (Item, error) {
+	item, err := scanItem(s.db.QueryRow(ctx,
+		"UPDATE items SET min_margin_percent=$2, updated_at=now() WHERE item_id=$1 AND deleted_at IS NULL RETURNING "+itemColumns,
+		itemID, percent,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return item, ErrNotFound
+	}
+	return item, err
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subahan-billing-backend/internal/pricing"
+)
+
+func TestBillUnitPriceOverrides(t *testing.T) {
+	s := testStore(t, Options{AllowNegativeStock: true})
+	ctx := context.Background()
+
+	plain, part, kit := testID("O"), testID("O"), testID("O")
+	for _, id := range []string{plain, part, kit} {
+		// Buying 6 with a 25% minimum margin: nothing below 8
+		exec(t, s, "INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price, min_margin_percent) VALUES ($1, $1, $1, 6, 10, 25)", id)
+	}
+	// The kit's own buying price says nothing of what its parts cost
+	exec(t, s, "UPDATE items SET buying_price = 1 WHERE item_id = $1", kit)
+	if _, err := s.SetKitComponents(ctx, kit, []KitComponent{{ItemID: part, Quantity: 1}}); err != nil {
+		t.Fatalf("SetKitComponents: %v", err)
+	}
+	bill := func(itemID string, unitPrice float64) (Bill, error) {
+		return s.CreateBill(ctx, BillCreate{Items: []BillItemCreate{{ItemID: itemID, Quantity: 1, UnitPrice: &unitPrice}}}, "clerk")
+	}
+
+	b, err := bill(plain, 10)
+	if err != nil {
+		t.Fatalf("CreateBill at the catalog price: %v", err)
+	}
+	for _, applied := range b.Items[0].Pricing {
+		if applied.Rule == pricing.RuleOverride {
+			t.Errorf("catalog price sent as unitPrice is logged as an override: %+v", b.Items[0].Pricing)
+		}
+	}
+	var logged int
+	if err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM price_overrides WHERE bill_id=$1", b.ID).Scan(&logged); err != nil {
+		t.Fatal(err)
+	}
+	if logged != 0 {
+		t.Errorf("price_overrides rows for the catalog price = %d, want 0", logged)
+	}
+
+	for _, itemID := range []string{plain, kit} {
+		_, err := bill(itemID, 7)
+		var marginErr *MarginError
+		if !errors.As(err, &marginErr) {
+			t.Errorf("%s at 7: got %v, want a *MarginError", itemID, err)
+		}
+	}
+}
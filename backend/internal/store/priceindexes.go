@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"subahan-billing-backend/internal/pricing"
+)
+
+const priceIndexSelectSQL = `
+	SELECT x.id, x.name, x.unit, v.value, to_char(v.effective_date, 'YYYY-MM-DD'),
+	       (SELECT COUNT(*) FROM items i WHERE i.price_index_id = x.id AND i.deleted_at IS NULL),
+	       x.created_at, x.updated_at
+	FROM price_indexes x
+	LEFT JOIN LATERAL (
+		SELECT value, effective_date FROM price_index_values
+		WHERE price_index_id = x.id ORDER BY effective_date DESC LIMIT 1
+	) v ON TRUE`
+
+func scanPriceIndex(row pgx.Row) (PriceIndex, error) {
+	var x PriceIndex
+	err := row.Scan(&x.ID, &x.Name, &x.Unit, &x.LatestValue, &x.LatestDate, &x.LinkedItemCount, &x.CreatedAt, &x.UpdatedAt)
+	return x, err
+}
+
+const priceIndexValueColumns = "id, price_index_id, to_char(effective_date, 'YYYY-MM-DD'), value, posted_by, posted_at"
+
+func scanPriceIndexValue(row pgx.Row) (PriceIndexValue, error) {
+	var v PriceIndexValue
+	err := row.Scan(&v.ID, &v.PriceIndexID, &v.EffectiveDate, &v.Value, &v.PostedBy, &v.PostedAt)
+	return v, err
+}
+
+func (s *Store) ListPriceIndexes(ctx context.Context) ([]PriceIndex, error) {
+	rows, err := s.db.Query(ctx, priceIndexSelectSQL+" ORDER BY lower(x.name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := []PriceIndex{}
+	for rows.Next() {
+		x, err := scanPriceIndex(rows)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, x)
+	}
+	return indexes, rows.Err()
+}
+
+func (s *Store) GetPriceIndex(ctx context.Context, id string) (PriceIndex, error) {
+	x, err := scanPriceIndex(s.db.QueryRow(ctx, priceIndexSelectSQL+" WHERE x.id = $1", id))
+	if err != nil {
+		return x, ErrNotFound
+	}
+	return x, nil
+}
+
+func (s *Store) CreatePriceIndex(ctx context.Context, input PriceIndexInput) (PriceIndex, error) {
+	var id string
+	err := s.db.QueryRow(ctx,
+		"INSERT INTO price_indexes (name, unit) VALUES ($1, $2) RETURNING id",
+		strings.TrimSpace(input.Name), strings.TrimSpace(input.Unit),
+	).Scan(&id)
+	if err != nil {
+		return PriceIndex{}, dbError(err)
+	}
+	return s.GetPriceIndex(ctx, id)
+}
+
+func (s *Store) UpdatePriceIndex(ctx context.Context, id string, input PriceIndexInput) (PriceIndex, error) {
+	cmd, err := s.db.Exec(ctx,
+		"UPDATE price_indexes SET name=$2, unit=$3, updated_at=now() WHERE id=$1",
+		id, strings.TrimSpace(input.Name), strings.TrimSpace(input.Unit),
+	)
+	if err != nil {
+		return PriceIndex{}, dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return PriceIndex{}, ErrNotFound
+	}
+	return s.GetPriceIndex(ctx, id)
+}
+
+// DeletePriceIndex removes an index no item is linked to; otherwise it
+// returns ErrConflict.
+func (s *Store) DeletePriceIndex(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM price_indexes WHERE id=$1", id)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return ErrConflict
+		}
+		return dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListPriceIndexValues returns an index's values, newest first.
+func (s *Store) ListPriceIndexValues(ctx context.Context, id string) ([]PriceIndexValue, error) {
+	if _, err := s.GetPriceIndex(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(ctx, "SELECT "+priceIndexValueColumns+" FROM price_index_values WHERE price_index_id=$1 ORDER BY effective_date DESC", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []PriceIndexValue{}
+	for rows.Next() {
+		v, err := scanPriceIndexValue(rows)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// PostPriceIndexValue records the index value for a date ("" for today in
+// the business time zone), replacing one already posted for that date.
+// Future dates are refused, as nothing would apply them once they arrive.
+// When it is the index's latest value, every live Wire/Box item linked to
+// the index gets base price = value × factor and its selling price
+// recomputed from sell%, with the changes recorded in price history against
+// user.
+func (s *Store) PostPriceIndexValue(ctx context.Context, id, date string, value float64, user string) (IndexReprice, error) {
+	result := IndexReprice{Items: []RepriceResult{}}
+	today := s.today()
+	if date == "" {
+		date = today
+	} else if date > today {
+		return result, invalidf("effectiveDate %s is after today, %s", date, today)
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	// One posting per index at a time, so "latest" cannot change under us
+	if err := tx.QueryRow(ctx, "SELECT id FROM price_indexes WHERE id=$1 FOR UPDATE", id).Scan(&id); err != nil {
+		return result, ErrNotFound
+	}
+	result.Value, err = scanPriceIndexValue(tx.QueryRow(ctx, `
+		INSERT INTO price_index_values (price_index_id, effective_date, value, posted_by)
+		VALUES ($1, $2::date, $3, NULLIF($4, ''))
+		ON CONFLICT (price_index_id, effective_date) DO UPDATE
+		SET value = EXCLUDED.value, posted_by = EXCLUDED.posted_by, posted_at = now()
+		RETURNING `+priceIndexValueColumns,
+		id, date, value, user,
+	))
+	if err != nil {
+		return result, err
+	}
+
+	var latest bool
+	if err := tx.QueryRow(ctx,
+		"SELECT NOT EXISTS (SELECT 1 FROM price_index_values WHERE price_index_id=$1 AND effective_date > $2::date)",
+		id, result.Value.EffectiveDate,
+	).Scan(&latest); err != nil {
+		return result, err
+	}
+	if latest {
+		if result.Items, err = applyIndexValue(ctx, tx, id, result.Value.Value, user); err != nil {
+			return result, err
+		}
+		result.Applied = true
+	}
+	return result, tx.Commit(ctx)
+}
+
+// applyIndexValue reprices the live Wire/Box items linked to an index.
+func applyIndexValue(ctx context.Context, tx pgx.Tx, indexID string, value float64, user string) ([]RepriceResult, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT item_id, name, arabic_name, index_factor FROM items
+		WHERE price_index_id=$1 AND is_wire_box AND deleted_at IS NULL AND index_factor IS NOT NULL
+		ORDER BY item_id
+	`, indexID)
+	if err != nil {
+		return nil, err
+	}
+	type linked struct {
+		result RepriceResult
+		factor float64
+	}
+	items := []linked{}
+	for rows.Next() {
+		var l linked
+		if err := rows.Scan(&l.result.ItemID, &l.result.Name, &l.result.ArabicName, &l.factor); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		l.result.IsWireBox = true
+		items = append(items, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]RepriceResult, 0, len(items))
+	for _, l := range items {
+		r := l.result
+		if err := repriceFromIndex(ctx, tx, &r, value, l.factor, user); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// repriceFromIndex sets a Wire/Box item's base price to value × factor and
+// recomputes its selling price, filling in r.Before and r.After. Items that
+// cannot be repriced are left alone with r.Skipped saying why.
+func repriceFromIndex(ctx context.Context, tx pgx.Tx, r *RepriceResult, value, factor float64, user string) error {
+	before, err := lockItemPrices(ctx, tx, r.ItemID)
+	if err != nil {
+		return err
+	}
+	r.Before, r.After = before, before
+	if before.SellPercentage == nil {
+		r.Skipped = "item has no sellPercentage"
+		return nil
+	}
+	after := before
+	base := roundFils(value * factor)
+	after.BuyingPrice = &base
+	after.SellingPrice = roundFils(pricing.WireBoxSellingPrice(base, *before.SellPercentage))
+	if base <= 0 || after.SellingPrice <= 0 {
+		r.Skipped = "new price would not be positive"
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE items SET buying_price=$2, selling_price=$3, updated_at=now() WHERE item_id=$1",
+		r.ItemID, after.BuyingPrice, after.SellingPrice,
+	); err != nil {
+		return err
+	}
+	r.After = after
+	return recordPriceChanges(ctx, tx, r.ItemID, before, after, user)
+}
+
+// LinkItemPriceIndex ties a Wire/Box item to an index, or unlinks it when
+// link.PriceIndexID is nil. Without a factor, the factor is derived from
+// the item's current base price and the index's latest value so linking
+// changes no price; with one, the base price is set from the latest value
+// straight away.
+func (s *Store) LinkItemPriceIndex(ctx context.Context, itemID string, link PriceIndexLink, user string) (Item, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Item{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var isWireBox bool
+	var base *float64
+	err = tx.QueryRow(ctx, "SELECT is_wire_box, buying_price FROM items WHERE item_id=$1 AND deleted_at IS NULL FOR UPDATE", itemID).Scan(&isWireBox, &base)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Item{}, ErrNotFound
+	}
+	if err != nil {
+		return Item{}, err
+	}
+
+	factor := link.Factor
+	var latest *float64
+	if link.PriceIndexID == nil {
+		factor = nil
+	} else {
+		if !isWireBox {
+			return Item{}, invalidf("only Wire/Box items can follow a price index")
+		}
+		err := tx.QueryRow(ctx, `
+			SELECT (SELECT value FROM price_index_values WHERE price_index_id = x.id ORDER BY effective_date DESC LIMIT 1)
+			FROM price_indexes x WHERE x.id=$1
+		`, *link.PriceIndexID).Scan(&latest)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Item{}, invalidf("price index not found")
+		}
+		if err != nil {
+			return Item{}, dbError(err)
+		}
+		if factor == nil {
+			if latest == nil || base == nil {
+				return Item{}, invalidf("factor is required until the index has a value and the item a base price")
+			}
+			derived := *base / *latest
+			factor = &derived
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE items SET price_index_id=$2, index_factor=$3, updated_at=now() WHERE item_id=$1",
+		itemID, link.PriceIndexID, factor,
+	); err != nil {
+		return Item{}, err
+	}
+	if link.Factor != nil && latest != nil {
+		r := RepriceResult{ItemID: itemID}
+		if err := repriceFromIndex(ctx, tx, &r, *latest, *factor, user); err != nil {
+			return Item{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Item{}, err
+	}
+	return s.GetItem(ctx, itemID)
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// A value dated after today in the business time zone is refused; one left
+// undated takes that zone's today.
+func TestPostPriceIndexValueRefusesFutureDates(t *testing.T) {
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	s := testStore(t, Options{Location: loc})
+	ctx := context.Background()
+
+	index, err := s.CreatePriceIndex(ctx, PriceIndexInput{Name: testID("copper"), Unit: "kg"})
+	if err != nil {
+		t.Fatalf("CreatePriceIndex: %v", err)
+	}
+	today := time.Now().In(loc)
+	tomorrow := today.AddDate(0, 0, 1).Format("2006-01-02")
+
+	_, err = s.PostPriceIndexValue(ctx, index.ID, tomorrow, 10, "tester")
+	var invalidErr *InvalidError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("posting %s: got %v, want an *InvalidError", tomorrow, err)
+	}
+	result, err := s.PostPriceIndexValue(ctx, index.ID, "", 10, "tester")
+	if err != nil {
+		t.Fatalf("posting today: %v", err)
+	}
+	if got, want := result.Value.EffectiveDate, today.Format("2006-01-02"); got != want {
+		t.Errorf("effective date = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const priceListSelectSQL = `
+	SELECT pl.id, pl.name, pl.arabic_name, pl.adjustment_percent, pl.sell_percentage,
+	       (SELECT COUNT(*) FROM price_list_items pli WHERE pli.price_list_id = pl.id),
+	       pl.created_at, pl.updated_at
+	FROM price_lists pl`
+
+func scanPriceList(row pgx.Row) (PriceList, error) {
+	var pl PriceList
+	err := row.Scan(&pl.ID, &pl.Name, &pl.ArabicName, &pl.AdjustmentPercent, &pl.SellPercentage, &pl.ItemCount, &pl.CreatedAt, &pl.UpdatedAt)
+	return pl, err
+}
+
+func (s *Store) ListPriceLists(ctx context.Context) ([]PriceList, error) {
+	rows, err := s.db.Query(ctx, priceListSelectSQL+" ORDER BY lower(pl.name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []PriceList{}
+	for rows.Next() {
+		pl, err := scanPriceList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, pl)
+	}
+	return lists, rows.Err()
+}
+
+func (s *Store) GetPriceList(ctx context.Context, id string) (PriceList, error) {
+	pl, err := scanPriceList(s.db.QueryRow(ctx, priceListSelectSQL+" WHERE pl.id = $1", id))
+	if err != nil {
+		return pl, ErrNotFound
+	}
+	return pl, nil
+}
+
+func (s *Store) CreatePriceList(ctx context.Context, input PriceListInput) (PriceList, error) {
+	var id string
+	err := s.db.QueryRow(ctx,
+		"INSERT INTO price_lists (name, arabic_name, adjustment_percent, sell_percentage) VALUES ($1, $2, $3, $4) RETURNING id",
+		strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName), input.AdjustmentPercent, input.SellPercentage,
+	).Scan(&id)
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return PriceList{}, ErrConflict
+		}
+		return PriceList{}, err
+	}
+	return s.GetPriceList(ctx, id)
+}
+
+func (s *Store) UpdatePriceList(ctx context.Context, id string, input PriceListInput) (PriceList, error) {
+	cmd, err := s.db.Exec(ctx,
+		"UPDATE price_lists SET name=$2, arabic_name=$3, adjustment_percent=$4, sell_percentage=$5, updated_at=now() WHERE id=$1",
+		id, strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName), input.AdjustmentPercent, input.SellPercentage,
+	)
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return PriceList{}, ErrConflict
+		}
+		return PriceList{}, err
+	}
+	if cmd.RowsAffected() == 0 {
+		return PriceList{}, ErrNotFound
+	}
+	return s.GetPriceList(ctx, id)
+}
+
+// DeletePriceList removes a list and its item prices. Customers on it fall
+// back to catalog prices; past bills keep the prices they were billed at.
+func (s *Store) DeletePriceList(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM price_lists WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) ListPriceListItems(ctx context.Context, listID string) ([]PriceListItem, error) {
+	if _, err := s.GetPriceList(ctx, listID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT pli.item_id, i.name, pli.price, pli.sell_percentage
+		FROM price_list_items pli
+		JOIN items i ON i.item_id = pli.item_id
+		WHERE pli.price_list_id = $1
+		ORDER BY i.name
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []PriceListItem{}
+	for rows.Next() {
+		var e PriceListItem
+		if err := rows.Scan(&e.ItemID, &e.Name, &e.Price, &e.SellPercentage); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SetPriceListItem adds or replaces one item's entry on a list. An unknown
+// list or item yields ErrInvalidReference.
+func (s *Store) SetPriceListItem(ctx context.Context, listID string, entry PriceListItem) (PriceListItem, error) {
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO price_list_items (price_list_id, item_id, price, sell_percentage)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (price_list_id, item_id) DO UPDATE SET price=EXCLUDED.price, sell_percentage=EXCLUDED.sell_percentage
+		RETURNING (SELECT name FROM items WHERE item_id = $2)
+	`, listID, entry.ItemID, entry.Price, entry.SellPercentage).Scan(&entry.Name)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return entry, ErrInvalidReference
+		}
+		return entry, err
+	}
+	return entry, nil
+}
+
+func (s *Store) DeletePriceListItem(ctx context.Context, listID, itemID string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM price_list_items WHERE price_list_id=$1 AND item_id=$2", listID, itemID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// QuoteItemPrice prices one unit of an item the way a bill line of quantity
+// without a unitPrice would be priced for the given customer and/or price
+// list.
+func (s *Store) QuoteItemPrice(ctx context.Context, itemID, unit string, quantity int, customerID, priceListID *string) (BillItem, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return BillItem{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	input := BillCreate{CustomerID: customerID, PriceListID: priceListID}
+	list, err := resolveBillCustomer(ctx, tx, &input)
+	if err != nil {
+		return BillItem{}, err
+	}
+	items, _, err := buildBillItems(ctx, tx, []BillItemCreate{{ItemID: itemID, Quantity: quantity, Unit: unit}}, list, s.today())
+	if err != nil {
+		return BillItem{}, err
+	}
+	return items[0], nil
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func pricesOf(item Item) ItemPrices {
+	return ItemPrices{item.BuyingPrice, item.SellingPrice, item.PurchasePercentage, item.SellPercentage}
+}
+
+// lockItemPrices reads an item's current prices and locks its row until the
+// transaction ends, so the recorded old values are the ones actually replaced.
+// It returns pgx.ErrNoRows when the item does not exist.
+func lockItemPrices(ctx context.Context, tx pgx.Tx, itemID string) (ItemPrices, error) {
+	var p ItemPrices
+	err := tx.QueryRow(ctx,
+		"SELECT buying_price, selling_price, purchase_percentage, sell_percentage FROM items WHERE item_id=$1 FOR UPDATE",
+		itemID,
+	).Scan(&p.BuyingPrice, &p.SellingPrice, &p.PurchasePercentage, &p.SellPercentage)
+	return p, err
+}
+
+// recordPriceChanges writes one history row per field that differs between
+// before and after.
+func recordPriceChanges(ctx context.Context, tx pgx.Tx, itemID string, before, after ItemPrices, changedBy string) error {
+	fields := []struct {
+		name     string
+		old, new *float64
+	}{
+		{PriceFieldBuying, before.BuyingPrice, after.BuyingPrice},
+		{PriceFieldSelling, &before.SellingPrice, &after.SellingPrice},
+		{PriceFieldPurchasePercent, before.PurchasePercentage, after.PurchasePercentage},
+		{PriceFieldSellPercent, before.SellPercentage, after.SellPercentage},
+	}
+	for _, f := range fields {
+		if samePrice(f.old, f.new) {
+			continue
+		}
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO item_price_changes (item_id, field, old_value, new_value, changed_by) VALUES ($1, $2, $3, $4, NULLIF($5, ''))",
+			itemID, f.name, f.old, f.new, changedBy,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// samePrice compares at the column's precision of three decimals.
+func samePrice(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return roundPrice(*a) == roundPrice(*b)
+}
+
+func roundPrice(v float64) int64 {
+	if v < 0 {
+		return -roundPrice(-v)
+	}
+	return int64(v*1000 + 0.5)
+}
+
+// ListPriceChanges returns an item's price history, newest first.
+func (s *Store) ListPriceChanges(ctx context.Context, itemID string) ([]PriceChange, error) {
+	var exists bool
+	if err := s.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM items WHERE item_id=$1)", itemID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, item_id, field, old_value, new_value, changed_by, changed_at
+		FROM item_price_changes
+		WHERE item_id=$1
+		ORDER BY id DESC
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []PriceChange{}
+	for rows.Next() {
+		var c PriceChange
+		if err := rows.Scan(&c.ID, &c.ItemID, &c.Field, &c.OldValue, &c.NewValue, &c.ChangedBy, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// PriceChangesSince lists every item price field changed at or after since,
+// collapsing repeated edits into a single from/to movement. Fields edited
+// back to where they started are left out.
+func (s *Store) PriceChangesSince(ctx context.Context, since time.Time) ([]PriceMovement, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT * FROM (
+			SELECT c.item_id, i.name, i.arabic_name, c.field,
+			       (array_agg(c.old_value ORDER BY c.id))[1] AS from_value,
+			       (array_agg(c.new_value ORDER BY c.id DESC))[1] AS to_value,
+			       COUNT(*), MAX(c.changed_at) AS last_changed_at
+			FROM item_price_changes c
+			JOIN items i ON i.item_id = c.item_id
+			WHERE c.changed_at >= $1
+			GROUP BY c.item_id, i.name, i.arabic_name, c.field
+		) m
+		WHERE from_value IS DISTINCT FROM to_value
+		ORDER BY last_changed_at DESC, item_id, field
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []PriceMovement{}
+	for rows.Next() {
+		var m PriceMovement
+		if err := rows.Scan(&m.ItemID, &m.Name, &m.ArabicName, &m.Field, &m.From, &m.To, &m.Changes, &m.LastChangedAt); err != nil {
+			return nil, err
+		}
+		report = append(report, m)
+	}
+	return report, rows.Err()
+}
+
+// RepriceItems runs reprice over every live item matching filter and, when
+// commit is set, writes the new prices and their history in one transaction.
+// Matching rows stay locked while reprice runs, so the diff returned is the
+// change actually applied. reprice returns an error to skip an item; items
+// whose prices come out unchanged are left out of the result.
+func (s *Store) RepriceItems(ctx context.Context, filter ItemFilter, reprice func(Item) (ItemPrices, error), commit bool, changedBy string) ([]RepriceResult, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	filter.IncludeDeleted = false
+	conditions, args := filter.where(nil)
+	query := "SELECT " + itemColumns + " FROM items WHERE " + strings.Join(conditions, " AND ") + " ORDER BY name"
+	if commit {
+		query += " FOR UPDATE"
+	}
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := []RepriceResult{}
+	for _, item := range items {
+		result := RepriceResult{ItemID: item.ItemID, Name: item.Name, ArabicName: item.ArabicName, IsWireBox: item.IsWireBox, Before: pricesOf(item)}
+		after, err := reprice(item)
+		if err != nil {
+			result.After = result.Before
+			result.Skipped = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if samePrices(result.Before, after) {
+			continue
+		}
+		result.After = after
+
+		if commit {
+			if _, err := tx.Exec(ctx,
+				"UPDATE items SET buying_price=$2, selling_price=$3, purchase_percentage=$4, sell_percentage=$5, updated_at=now() WHERE item_id=$1",
+				item.ItemID, after.BuyingPrice, after.SellingPrice, after.PurchasePercentage, after.SellPercentage,
+			); err != nil {
+				return nil, err
+			}
+			if err := recordPriceChanges(ctx, tx, item.ItemID, result.Before, after, changedBy); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+
+	if commit {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func samePrices(a, b ItemPrices) bool {
+	return samePrice(a.BuyingPrice, b.BuyingPrice) &&
+		samePrice(&a.SellingPrice, &b.SellingPrice) &&
+		samePrice(a.PurchasePercentage, b.PurchasePercentage) &&
+		samePrice(a.SellPercentage, b.SellPercentage)
+}
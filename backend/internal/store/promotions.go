@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"subahan-billing-backend/internal/pricing"
+)
+
+const quantityBreakColumns = "id, item_id, category_id, min_quantity, price, discount_percent, created_at, updated_at"
+
+func scanQuantityBreak(row pgx.Row) (QuantityBreak, error) {
+	var b QuantityBreak
+	err := row.Scan(&b.ID, &b.ItemID, &b.CategoryID, &b.MinQuantity, &b.Price, &b.DiscountPercent, &b.CreatedAt, &b.UpdatedAt)
+	return b, err
+}
+
+const promotionColumns = `id, name, arabic_name, item_id, category_id, price, discount_percent,
+	to_char(starts_on, 'YYYY-MM-DD'), to_char(ends_on, 'YYYY-MM-DD'), created_at, updated_at`
+
+// scanPromotion reads promotionColumns, marking the promotion running when
+// today (YYYY-MM-DD) falls within it.
+func scanPromotion(row pgx.Row, today string) (Promotion, error) {
+	var p Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.ArabicName, &p.ItemID, &p.CategoryID, &p.Price, &p.DiscountPercent,
+		&p.StartsOn, &p.EndsOn, &p.CreatedAt, &p.UpdatedAt)
+	p.Running = p.StartsOn <= today && today <= p.EndsOn
+	return p, err
+}
+
+func (f PricingRuleFilter) where(args []any) ([]string, []any) {
+	conditions := []string{}
+	if f.ItemID != "" {
+		args = append(args, f.ItemID)
+		conditions = append(conditions, fmt.Sprintf("item_id = $%d", len(args)))
+	}
+	if f.CategoryID != "" {
+		args = append(args, f.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("category_id = $%d", len(args)))
+	}
+	return conditions, args
+}
+
+// ListQuantityBreaks returns breaks by item or category, smallest minimum
+// first.
+func (s *Store) ListQuantityBreaks(ctx context.Context, filter PricingRuleFilter) ([]QuantityBreak, error) {
+	conditions, args := filter.where(nil)
+	query := "SELECT " + quantityBreakColumns + " FROM quantity_breaks"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY item_id NULLS LAST, category_id, min_quantity"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	breaks := []QuantityBreak{}
+	for rows.Next() {
+		b, err := scanQuantityBreak(rows)
+		if err != nil {
+			return nil, err
+		}
+		breaks = append(breaks, b)
+	}
+	return breaks, rows.Err()
+}
+
+// CreateQuantityBreak adds a break. An unknown item or category yields
+// ErrInvalidReference and a second break at the same minimum ErrConflict.
+func (s *Store) CreateQuantityBreak(ctx context.Context, input QuantityBreakInput) (QuantityBreak, error) {
+	b, err := scanQuantityBreak(s.db.QueryRow(ctx, `
+		INSERT INTO quantity_breaks (item_id, category_id, min_quantity, price, discount_percent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+quantityBreakColumns,
+		input.ItemID, input.CategoryID, input.MinQuantity, input.Price, input.DiscountPercent,
+	))
+	if err != nil {
+		return b, dbError(err)
+	}
+	return b, nil
+}
+
+func (s *Store) UpdateQuantityBreak(ctx context.Context, id string, input QuantityBreakInput) (QuantityBreak, error) {
+	b, err := scanQuantityBreak(s.db.QueryRow(ctx, `
+		UPDATE quantity_breaks SET item_id=$2, category_id=$3, min_quantity=$4, price=$5, discount_percent=$6, updated_at=now()
+		WHERE id=$1
+		RETURNING `+quantityBreakColumns,
+		id, input.ItemID, input.CategoryID, input.MinQuantity, input.Price, input.DiscountPercent,
+	))
+	if err != nil {
+		return b, dbError(err)
+	}
+	return b, nil
+}
+
+func (s *Store) DeleteQuantityBreak(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM quantity_breaks WHERE id=$1", id)
+	if err != nil {
+		return dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListPromotions returns promotions by item or category, latest ending
+// first.
+func (s *Store) ListPromotions(ctx context.Context, filter PricingRuleFilter) ([]Promotion, error) {
+	today := s.today()
+	conditions, args := filter.where(nil)
+	if filter.Running {
+		args = append(args, today)
+		conditions = append(conditions, fmt.Sprintf("$%d::date BETWEEN starts_on AND ends_on", len(args)))
+	}
+	query := "SELECT " + promotionColumns + " FROM promotions"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY ends_on DESC, lower(name)"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	promotions := []Promotion{}
+	for rows.Next() {
+		p, err := scanPromotion(rows, today)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+func (s *Store) GetPromotion(ctx context.Context, id string) (Promotion, error) {
+	p, err := scanPromotion(s.db.QueryRow(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE id=$1", id), s.today())
+	if err != nil {
+		return p, ErrNotFound
+	}
+	return p, nil
+}
+
+// CreatePromotion adds a promotion. An unknown item or category yields
+// ErrInvalidReference.
+func (s *Store) CreatePromotion(ctx context.Context, input PromotionInput) (Promotion, error) {
+	p, err := scanPromotion(s.db.QueryRow(ctx, `
+		INSERT INTO promotions (name, arabic_name, item_id, category_id, price, discount_percent, starts_on, ends_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+promotionColumns,
+		strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName), input.ItemID, input.CategoryID,
+		input.Price, input.DiscountPercent, input.StartsOn, input.EndsOn,
+	), s.today())
+	if err != nil {
+		return p, dbError(err)
+	}
+	return p, nil
+}
+
+// UpdatePromotion replaces a promotion. Bills already sold under it keep
+// their prices.
+func (s *Store) UpdatePromotion(ctx context.Context, id string, input PromotionInput) (Promotion, error) {
+	p, err := scanPromotion(s.db.QueryRow(ctx, `
+		UPDATE promotions SET name=$2, arabic_name=$3, item_id=$4, category_id=$5, price=$6, discount_percent=$7,
+		       starts_on=$8, ends_on=$9, updated_at=now()
+		WHERE id=$1
+		RETURNING `+promotionColumns,
+		id, strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName), input.ItemID, input.CategoryID,
+		input.Price, input.DiscountPercent, input.StartsOn, input.EndsOn,
+	), s.today())
+	if err != nil {
+		return p, dbError(err)
+	}
+	return p, nil
+}
+
+// DeletePromotion removes a promotion; bill lines sold under it keep its
+// name.
+func (s *Store) DeletePromotion(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM promotions WHERE id=$1", id)
+	if err != nil {
+		return dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// itemCategoriesSQL lists the categories an item ($1) sits under, its own
+// first.
+const itemCategoriesSQL = `WITH RECURSIVE up AS (
+	SELECT c.id, c.parent_id, c.name, 1 AS depth
+	FROM categories c JOIN items i ON i.category_id = c.id
+	WHERE i.item_id = $1
+	UNION ALL
+	SELECT c.id, c.parent_id, c.name, up.depth + 1
+	FROM categories c JOIN up ON c.id = up.parent_id
+)`
+
+// loadPricingRules returns the quantity breaks and the promotions running
+// on today (YYYY-MM-DD) that apply to an item. Breaks come from the item itself or, failing that, the
+// nearest category that has any; promotions come from the item and every
+// category above it.
+func loadPricingRules(ctx context.Context, q querier, itemID, today string) ([]pricing.Break, []pricing.Promotion, error) {
+	rows, err := q.Query(ctx, itemCategoriesSQL+`
+		SELECT qb.min_quantity, qb.price, qb.discount_percent, COALESCE(up.depth, 0), COALESCE(up.name, '')
+		FROM quantity_breaks qb
+		LEFT JOIN up ON up.id = qb.category_id
+		WHERE qb.item_id = $1 OR up.id IS NOT NULL
+		ORDER BY 4, qb.min_quantity
+	`, itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	breaks := []pricing.Break{}
+	nearest := -1
+	for rows.Next() {
+		var b pricing.Break
+		var depth int
+		if err := rows.Scan(&b.MinQuantity, &b.Price, &b.DiscountPercent, &depth, &b.Source); err != nil {
+			return nil, nil, err
+		}
+		if nearest >= 0 && depth != nearest {
+			break
+		}
+		nearest = depth
+		if depth == 0 {
+			b.Source = "item"
+		}
+		breaks = append(breaks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	rows, err = q.Query(ctx, itemCategoriesSQL+`
+		SELECT p.id, p.name, p.price, p.discount_percent
+		FROM promotions p
+		WHERE (p.item_id = $1 OR p.category_id IN (SELECT id FROM up))
+		  AND $2::date BETWEEN p.starts_on AND p.ends_on
+		ORDER BY p.ends_on, p.id
+	`, itemID, today)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	promotions := []pricing.Promotion{}
+	for rows.Next() {
+		var p pricing.Promotion
+		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.DiscountPercent); err != nil {
+			return nil, nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return breaks, promotions, rows.Err()
+}
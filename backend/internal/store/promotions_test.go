@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// A promotion's dates are days in the business time zone, which can be a
+// day ahead of the database's.
+func TestPromotionRunsOnBusinessDays(t *testing.T) {
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	s := testStore(t, Options{Location: loc})
+	ctx := context.Background()
+
+	itemID := testID("P")
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price) VALUES ($1, $1, $1, 10)", itemID)
+	today := time.Now().In(loc).Format("2006-01-02")
+	discount := 50.0
+	p, err := s.CreatePromotion(ctx, PromotionInput{Name: "Today only", ItemID: &itemID, DiscountPercent: &discount, StartsOn: today, EndsOn: today})
+	if err != nil {
+		t.Fatalf("CreatePromotion: %v", err)
+	}
+	if !p.Running {
+		t.Errorf("promotion on %s is not running", today)
+	}
+
+	running, err := s.ListPromotions(ctx, PricingRuleFilter{ItemID: itemID, Running: true})
+	if err != nil {
+		t.Fatalf("ListPromotions: %v", err)
+	}
+	if len(running) != 1 {
+		t.Errorf("running promotions = %d, want 1", len(running))
+	}
+	quote, err := s.QuoteItemPrice(ctx, itemID, "", 1, nil, nil)
+	if err != nil {
+		t.Fatalf("QuoteItemPrice: %v", err)
+	}
+	if quote.UnitPrice != 5 {
+		t.Errorf("quoted price = %v, want 5", quote.UnitPrice)
+	}
+}
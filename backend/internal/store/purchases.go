@@ -0,0 +1,411 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"subahan-billing-backend/internal/pricing"
+)
+
+const purchaseColumns = `p.id, p.supplier_id, s.name, p.invoice_number, to_char(p.invoice_date, 'YYYY-MM-DD'), p.status,
+	p.extra_costs, p.subtotal, p.total_amount, p.update_prices, p.note, p.created_by, p.posted_by, p.posted_at,
+	p.created_at, p.updated_at`
+
+const purchaseFrom = " FROM purchase_invoices p JOIN suppliers s ON s.id = p.supplier_id"
+
+func scanPurchase(row pgx.Row) (PurchaseInvoice, error) {
+	var p PurchaseInvoice
+	err := row.Scan(&p.ID, &p.SupplierID, &p.SupplierName, &p.InvoiceNumber, &p.InvoiceDate, &p.Status,
+		&p.ExtraCosts, &p.Subtotal, &p.TotalAmount, &p.UpdatePrices, &p.Note, &p.CreatedBy, &p.PostedBy, &p.PostedAt,
+		&p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+// where returns the SQL conditions for the filter against purchase_invoices
+// aliased p. To is exclusive.
+func (f PurchaseFilter) where(args []any) ([]string, []any) {
+	conditions := []string{}
+	if f.SupplierID != "" {
+		args = append(args, f.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("p.supplier_id = $%d", len(args)))
+	}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conditions = append(conditions, fmt.Sprintf("p.status = $%d", len(args)))
+	}
+	if f.From != nil {
+		args = append(args, *f.From)
+		conditions = append(conditions, fmt.Sprintf("p.invoice_date >= $%d::date", len(args)))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		conditions = append(conditions, fmt.Sprintf("p.invoice_date < $%d::date", len(args)))
+	}
+	return conditions, args
+}
+
+// ListPurchases returns purchase invoices without their lines, newest
+// invoice date first.
+func (s *Store) ListPurchases(ctx context.Context, filter PurchaseFilter, limit, offset int) ([]PurchaseInvoice, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	conditions, args := filter.where(nil)
+	query := "SELECT " + purchaseColumns + purchaseFrom
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY p.invoice_date DESC, p.created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	purchases := []PurchaseInvoice{}
+	for rows.Next() {
+		p, err := scanPurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
+
+// GetPurchase returns a purchase invoice with its lines.
+func (s *Store) GetPurchase(ctx context.Context, id string) (PurchaseInvoice, error) {
+	return getPurchase(ctx, s.db, id)
+}
+
+func getPurchase(ctx context.Context, q querier, id string) (PurchaseInvoice, error) {
+	p, err := scanPurchase(q.QueryRow(ctx, "SELECT "+purchaseColumns+purchaseFrom+" WHERE p.id=$1", id))
+	if err != nil {
+		return p, dbError(err)
+	}
+	p.Lines, err = loadPurchaseLines(ctx, q, id)
+	return p, err
+}
+
+func loadPurchaseLines(ctx context.Context, q querier, purchaseID string) ([]PurchaseLine, error) {
+	rows, err := q.Query(ctx, `
+		SELECT l.id, l.item_id, i.name, l.quantity, l.unit, l.unit_factor, l.unit_cost, l.landed_unit_cost
+		FROM purchase_invoice_lines l
+		JOIN items i ON i.item_id = l.item_id
+		WHERE l.purchase_invoice_id=$1
+		ORDER BY i.name, l.id
+	`, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []PurchaseLine{}
+	for rows.Next() {
+		var l PurchaseLine
+		if err := rows.Scan(&l.ID, &l.ItemID, &l.ItemName, &l.Quantity, &l.Unit, &l.UnitFactor, &l.UnitCost, &l.LandedUnitCost); err != nil {
+			return nil, err
+		}
+		l.LineTotal = roundFils(l.Quantity * l.UnitCost)
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// CreatePurchase stores a draft purchase invoice. Stock is untouched until
+// the invoice is posted.
+func (s *Store) CreatePurchase(ctx context.Context, input PurchaseInvoiceInput, user string) (PurchaseInvoice, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return PurchaseInvoice{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO purchase_invoices (supplier_id, invoice_number, invoice_date, extra_costs, update_prices, note, created_by)
+		VALUES ($1, $2, COALESCE(NULLIF($3, '')::date, CURRENT_DATE), $4, $5, $6, NULLIF($7, ''))
+		RETURNING id
+	`, input.SupplierID, input.InvoiceNumber, input.InvoiceDate, input.ExtraCosts, input.UpdatePrices, input.Note, user).Scan(&id)
+	if err != nil {
+		return PurchaseInvoice{}, dbError(err)
+	}
+	if err := insertPurchaseLines(ctx, tx, id, input); err != nil {
+		return PurchaseInvoice{}, err
+	}
+
+	p, err := getPurchase(ctx, tx, id)
+	if err != nil {
+		return p, err
+	}
+	return p, tx.Commit(ctx)
+}
+
+// UpdatePurchase replaces a draft's header and lines. Posted invoices are
+// final and give ErrConflict.
+func (s *Store) UpdatePurchase(ctx context.Context, id string, input PurchaseInvoiceInput) (PurchaseInvoice, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return PurchaseInvoice{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockDraftPurchase(ctx, tx, id); err != nil {
+		return PurchaseInvoice{}, err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE purchase_invoices
+		SET supplier_id=$2, invoice_number=$3, invoice_date=COALESCE(NULLIF($4, '')::date, invoice_date),
+		    extra_costs=$5, update_prices=$6, note=$7, updated_at=now()
+		WHERE id=$1
+	`, id, input.SupplierID, input.InvoiceNumber, input.InvoiceDate, input.ExtraCosts, input.UpdatePrices, input.Note)
+	if err != nil {
+		return PurchaseInvoice{}, dbError(err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM purchase_invoice_lines WHERE purchase_invoice_id=$1", id); err != nil {
+		return PurchaseInvoice{}, err
+	}
+	if err := insertPurchaseLines(ctx, tx, id, input); err != nil {
+		return PurchaseInvoice{}, err
+	}
+
+	p, err := getPurchase(ctx, tx, id)
+	if err != nil {
+		return p, err
+	}
+	return p, tx.Commit(ctx)
+}
+
+// DeletePurchase removes a draft. Posted invoices give ErrConflict.
+func (s *Store) DeletePurchase(ctx context.Context, id string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockDraftPurchase(ctx, tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM purchase_invoices WHERE id=$1", id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// lockDraftPurchase locks a purchase invoice row and checks it is still a
+// draft, returning its extra costs.
+func lockDraftPurchase(ctx context.Context, tx pgx.Tx, id string) (float64, error) {
+	var status string
+	var extraCosts float64
+	err := tx.QueryRow(ctx, "SELECT status, extra_costs FROM purchase_invoices WHERE id=$1 FOR UPDATE", id).Scan(&status, &extraCosts)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	if status != PurchaseDraft {
+		return 0, ErrConflict
+	}
+	return extraCosts, nil
+}
+
+// insertPurchaseLines checks the lines against the catalog, stores them and
+// sets the invoice subtotal and total.
+func insertPurchaseLines(ctx context.Context, tx pgx.Tx, purchaseID string, input PurchaseInvoiceInput) error {
+	var subtotal float64
+	for _, line := range input.Lines {
+		var baseUnit string
+		var isKit bool
+		err := tx.QueryRow(ctx, "SELECT unit, is_kit FROM items WHERE item_id=$1 AND deleted_at IS NULL", line.ItemID).Scan(&baseUnit, &isKit)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return invalidf("item %s not found", line.ItemID)
+		}
+		if err != nil {
+			return err
+		}
+		if isKit {
+			return invalidf("item %s is a kit; purchase its components instead", line.ItemID)
+		}
+		factor, err := saleUnitFactor(ctx, tx, line.ItemID, baseUnit, line.Unit)
+		if err != nil {
+			return err
+		}
+		unit := baseUnit
+		if line.Unit != "" {
+			unit = line.Unit
+		}
+
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO purchase_invoice_lines (purchase_invoice_id, item_id, quantity, unit, unit_factor, unit_cost) VALUES ($1, $2, $3, $4, $5, $6)",
+			purchaseID, line.ItemID, line.Quantity, unit, factor, line.UnitCost,
+		); err != nil {
+			return err
+		}
+		subtotal += roundFils(line.Quantity * line.UnitCost)
+	}
+
+	_, err := tx.Exec(ctx,
+		"UPDATE purchase_invoices SET subtotal=$2, total_amount=$2 + extra_costs WHERE id=$1",
+		purchaseID, roundFils(subtotal),
+	)
+	return err
+}
+
+// PostPurchase books a draft's lines into stock and marks it posted. Extra
+// costs are spread over the lines by value to give each line a landed cost
+// per base unit. When the invoice asks for it, that cost becomes the item's
+// buying price, or for Wire/Box items the base price that gives it after the
+// purchase discount.
+func (s *Store) PostPurchase(ctx context.Context, id string, user string) (PurchaseInvoice, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return PurchaseInvoice{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	extraCosts, err := lockDraftPurchase(ctx, tx, id)
+	if err != nil {
+		return PurchaseInvoice{}, err
+	}
+	p, err := getPurchase(ctx, tx, id)
+	if err != nil {
+		return p, err
+	}
+	if len(p.Lines) == 0 {
+		return p, invalidf("purchase has no lines")
+	}
+
+	var subtotal, baseQuantity float64
+	for _, l := range p.Lines {
+		subtotal += l.Quantity * l.UnitCost
+		baseQuantity += l.Quantity * l.UnitFactor
+	}
+
+	// Per item: landed value and base quantity, for the price update
+	type itemCost struct{ value, quantity float64 }
+	costs := map[string]*itemCost{}
+	reference := fmt.Sprintf("purchase %s", p.SupplierName)
+	if p.InvoiceNumber != nil {
+		reference += " #" + *p.InvoiceNumber
+	}
+
+	// Lines go in item order so concurrent postings lock stock rows alike
+	lines := append([]PurchaseLine(nil), p.Lines...)
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].ItemID < lines[j].ItemID })
+	for _, l := range lines {
+		value := l.Quantity * l.UnitCost
+		if subtotal > 0 {
+			value += extraCosts * value / subtotal
+		} else {
+			value += extraCosts * l.Quantity * l.UnitFactor / baseQuantity
+		}
+		quantity := l.Quantity * l.UnitFactor
+		landed := roundQuantity(value / quantity)
+
+		if _, err := tx.Exec(ctx, "UPDATE purchase_invoice_lines SET landed_unit_cost=$2 WHERE id=$1", l.ID, landed); err != nil {
+			return p, err
+		}
+		if err := s.moveStock(ctx, tx, StockMovement{
+			ItemID:            l.ItemID,
+			Kind:              StockPurchase,
+			Quantity:          quantity,
+			PurchaseInvoiceID: &p.ID,
+			Reference:         &reference,
+			CreatedBy:         &user,
+		}); err != nil {
+			return p, err
+		}
+
+		c, ok := costs[l.ItemID]
+		if !ok {
+			c = &itemCost{}
+			costs[l.ItemID] = c
+		}
+		c.value += value
+		c.quantity += quantity
+	}
+
+	if p.UpdatePrices {
+		ids := make([]string, 0, len(costs))
+		for itemID := range costs {
+			ids = append(ids, itemID)
+		}
+		sort.Strings(ids)
+		for _, itemID := range ids {
+			c := costs[itemID]
+			if err := applyLandedCost(ctx, tx, itemID, c.value/c.quantity, user); err != nil {
+				return p, err
+			}
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE purchase_invoices SET status=$2, posted_by=NULLIF($3, ''), posted_at=now(), updated_at=now() WHERE id=$1",
+		id, PurchasePosted, user,
+	); err != nil {
+		return p, err
+	}
+
+	p, err = getPurchase(ctx, tx, id)
+	if err != nil {
+		return p, err
+	}
+	return p, tx.Commit(ctx)
+}
+
+// applyLandedCost writes a landed cost per base unit back to an item and
+// records the price change. Normal items take it as their buying price.
+// Wire/Box items cost base × (1 - purchase%), so the base price is worked
+// back from it and the selling price follows sell% as usual.
+func applyLandedCost(ctx context.Context, tx pgx.Tx, itemID string, landed float64, user string) error {
+	before, err := lockItemPrices(ctx, tx, itemID)
+	if err != nil {
+		return err
+	}
+	var isWireBox, indexed bool
+	if err := tx.QueryRow(ctx, "SELECT is_wire_box, price_index_id IS NOT NULL FROM items WHERE item_id=$1", itemID).Scan(&isWireBox, &indexed); err != nil {
+		return err
+	}
+	if isWireBox && indexed {
+		// The base price follows its price index
+		return nil
+	}
+
+	after := before
+	if isWireBox {
+		var base float64
+		ok := before.PurchasePercentage != nil && before.SellPercentage != nil
+		if ok {
+			base, ok = pricing.WireBoxBase(landed, *before.PurchasePercentage)
+		}
+		if !ok {
+			return invalidf("item %s is missing its purchasePercentage or sellPercentage", itemID)
+		}
+		base = roundFils(base)
+		after.BuyingPrice = &base
+		after.SellingPrice = roundFils(pricing.WireBoxSellingPrice(base, *before.SellPercentage))
+	} else {
+		cost := roundFils(landed)
+		after.BuyingPrice = &cost
+	}
+	if after.BuyingPrice != nil && *after.BuyingPrice <= 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE items SET buying_price=$2, selling_price=$3, updated_at=now() WHERE item_id=$1",
+		itemID, after.BuyingPrice, after.SellingPrice,
+	); err != nil {
+		return err
+	}
+	return recordPriceChanges(ctx, tx, itemID, before, after, user)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+)
+
+// SetReorderSettings stores an item's reorder point and quantity.
+func (s *Store) SetReorderSettings(ctx context.Context, itemID string, settings ReorderSettings) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO stock_levels (item_id, reorder_point, reorder_quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (item_id) DO UPDATE SET reorder_point = EXCLUDED.reorder_point, reorder_quantity = EXCLUDED.reorder_quantity, updated_at = now()
+	`, itemID, settings.ReorderPoint, settings.ReorderQuantity)
+	if isPgError(err, pgForeignKeyViolation) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// RefreshReorderList rebuilds the low-stock list. Average daily sales are
+// net of returns over the last windowDays. An item is low once on hand falls
+// to its reorder point; items without one use leadDays of average sales.
+// The suggestion covers the reorder quantity, or windowDays of sales when
+// none is set, and at least brings stock back up to the reorder point.
+func (s *Store) RefreshReorderList(ctx context.Context, windowDays, leadDays int) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM reorder_suggestions"); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		WITH sales AS (
+			SELECT item_id, -SUM(quantity) AS sold
+			FROM stock_movements
+			WHERE kind IN ('sale', 'return') AND created_at >= now() - make_interval(days => $1)
+			GROUP BY item_id
+		), levels AS (
+			SELECT i.item_id, COALESCE(sl.on_hand, 0) AS on_hand, sl.reorder_point, sl.reorder_quantity,
+			       GREATEST(COALESCE(sa.sold, 0), 0) / $1::numeric AS avg_daily
+			FROM items i
+			LEFT JOIN stock_levels sl ON sl.item_id = i.item_id
+			LEFT JOIN sales sa ON sa.item_id = i.item_id
+			WHERE i.deleted_at IS NULL AND (sl.reorder_point IS NOT NULL OR sa.sold > 0)
+		), points AS (
+			SELECT *, COALESCE(reorder_point, avg_daily * $2) AS point FROM levels
+		)
+		INSERT INTO reorder_suggestions (item_id, on_hand, avg_daily_sales, reorder_point, suggested_quantity, days_of_cover)
+		SELECT item_id, on_hand, round(avg_daily, 4), round(point, 4),
+		       ceil(GREATEST(COALESCE(reorder_quantity, avg_daily * $1), point - on_hand, 1)),
+		       CASE WHEN avg_daily > 0 THEN round(GREATEST(on_hand, 0) / avg_daily, 1) END
+		FROM points
+		WHERE on_hand <= point
+	`, windowDays, leadDays)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListReorderSuggestions returns the list from the last refresh, most urgent
+// first.
+func (s *Store) ListReorderSuggestions(ctx context.Context) ([]ReorderSuggestion, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT r.item_id, i.name, i.arabic_name, i.unit, r.on_hand, r.avg_daily_sales,
+		       r.reorder_point, r.suggested_quantity, r.days_of_cover, r.computed_at
+		FROM reorder_suggestions r
+		JOIN items i ON i.item_id = r.item_id
+		WHERE i.deleted_at IS NULL
+		ORDER BY r.days_of_cover NULLS LAST, r.on_hand - r.reorder_point, i.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []ReorderSuggestion{}
+	for rows.Next() {
+		var r ReorderSuggestion
+		if err := rows.Scan(&r.ItemID, &r.Name, &r.ArabicName, &r.Unit, &r.OnHand, &r.AvgDailySales, &r.ReorderPoint, &r.SuggestedQuantity, &r.DaysOfCover, &r.ComputedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,412 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ReportRange bounds a report by bill creation time. Either end may be nil;
+// To is exclusive.
+type ReportRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+type CategorySales struct {
+	CategoryID *string `json:"categoryId"`
+	Path       string  `json:"path"`
+	ArabicPath string  `json:"arabicPath"`
+	Quantity   int     `json:"quantity"`
+	Revenue    float64 `json:"revenue"`
+	BillCount  int     `json:"billCount"`
+}
+
+type BrandSales struct {
+	BrandID    *string `json:"brandId"`
+	Name       string  `json:"name"`
+	ArabicName string  `json:"arabicName"`
+	Quantity   int     `json:"quantity"`
+	Revenue    float64 `json:"revenue"`
+	BillCount  int     `json:"billCount"`
+}
+
+// SalesByCategory totals bill lines per category. With rollup, sales in
+// subcategories are credited to their top-level category. Lines for items
+// without a category are grouped under a nil CategoryID.
+func (s *Store) SalesByCategory(ctx context.Context, period ReportRange, rollup bool) ([]CategorySales, error) {
+	rows, err := s.db.Query(ctx, categoryPathsSQL+`,
+		sales AS (
+			SELECT CASE WHEN $3 THEN p.root_id ELSE p.id END AS category_id,
+			       bi.bill_id, bi.quantity, bi.unit_price
+			FROM bill_items bi
+			JOIN bills b ON b.id = bi.bill_id
+			LEFT JOIN items i ON i.item_id = bi.item_id
+			LEFT JOIN paths p ON p.id = i.category_id
+			WHERE ($1::timestamptz IS NULL OR b.created_at >= $1)
+			  AND ($2::timestamptz IS NULL OR b.created_at < $2)
+		)
+		SELECT s.category_id, COALESCE(p.path, ''), COALESCE(p.arabic_path, ''),
+		       SUM(s.quantity), SUM(s.quantity * s.unit_price), COUNT(DISTINCT s.bill_id)
+		FROM sales s
+		LEFT JOIN paths p ON p.id = s.category_id
+		GROUP BY s.category_id, p.path, p.arabic_path
+		ORDER BY 5 DESC
+	`, period.From, period.To, rollup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []CategorySales{}
+	for rows.Next() {
+		var row CategorySales
+		if err := rows.Scan(&row.CategoryID, &row.Path, &row.ArabicPath, &row.Quantity, &row.Revenue, &row.BillCount); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// SalesByBrand totals bill lines per brand, with unbranded items under a nil BrandID.
+func (s *Store) SalesByBrand(ctx context.Context, period ReportRange) ([]BrandSales, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT br.id, COALESCE(br.name, ''), COALESCE(br.arabic_name, ''),
+		       SUM(bi.quantity), SUM(bi.quantity * bi.unit_price), COUNT(DISTINCT bi.bill_id)
+		FROM bill_items bi
+		JOIN bills b ON b.id = bi.bill_id
+		LEFT JOIN items i ON i.item_id = bi.item_id
+		LEFT JOIN brands br ON br.id = i.brand_id
+		WHERE ($1::timestamptz IS NULL OR b.created_at >= $1)
+		  AND ($2::timestamptz IS NULL OR b.created_at < $2)
+		GROUP BY br.id, br.name, br.arabic_name
+		ORDER BY 5 DESC
+	`, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []BrandSales{}
+	for rows.Next() {
+		var row BrandSales
+		if err := rows.Scan(&row.BrandID, &row.Name, &row.ArabicName, &row.Quantity, &row.Revenue, &row.BillCount); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// Sales summary groupings
+const (
+	GroupByDay   = "day"
+	GroupByWeek  = "week"
+	GroupByMonth = "month"
+)
+
+// SalesFilter narrows the sales summary and profit report. A category
+// includes its subcategories; a branch matches only bills rung up there. With an item or category, only the matching lines count
+// towards a bill's value.
+type SalesFilter struct {
+	ReportRange
+	CustomerID string
+	ItemID     string
+	CategoryID string
+	BranchID   string
+}
+
+func (f SalesFilter) where(args []any) ([]string, []any) {
+	conditions := []string{}
+	if f.From != nil {
+		args = append(args, *f.From)
+		conditions = append(conditions, fmt.Sprintf("b.created_at >= $%d", len(args)))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		conditions = append(conditions, fmt.Sprintf("b.created_at < $%d", len(args)))
+	}
+	if f.CustomerID != "" {
+		args = append(args, f.CustomerID)
+		conditions = append(conditions, fmt.Sprintf("b.customer_id = $%d", len(args)))
+	}
+	if f.BranchID != "" {
+		args = append(args, f.BranchID)
+		conditions = append(conditions, fmt.Sprintf("b.branch_id = $%d", len(args)))
+	}
+	if f.ItemID != "" {
+		args = append(args, f.ItemID)
+		conditions = append(conditions, fmt.Sprintf("bi.item_id = $%d", len(args)))
+	}
+	if f.CategoryID != "" {
+		args = append(args, f.CategoryID)
+		conditions = append(conditions, fmt.Sprintf(
+			"bi.item_id IN (SELECT item_id FROM items WHERE category_id IN (%s))", categorySubtreeSQL(len(args))))
+	}
+	return conditions, args
+}
+
+// SalesTotals is the value of a set of bills.
+type SalesTotals struct {
+	BillCount   int     `json:"billCount"`
+	Total       float64 `json:"total"`
+	AverageBill float64 `json:"averageBill"`
+}
+
+// SalesPeriod is one day, week (from Monday) or month of sales, named by
+// its first day in the business time zone.
+type SalesPeriod struct {
+	Start string `json:"start"`
+	SalesTotals
+}
+
+type SalesSummary struct {
+	GroupBy  string        `json:"groupBy"`
+	TimeZone string        `json:"timeZone"`
+	Totals   SalesTotals   `json:"totals"`
+	Periods  []SalesPeriod `json:"periods"`
+}
+
+// SalesSummary totals bills per day, week or month in the business time
+// zone. Periods without sales are left out.
+func (s *Store) SalesSummary(ctx context.Context, filter SalesFilter, groupBy string) (SalesSummary, error) {
+	summary := SalesSummary{GroupBy: groupBy, TimeZone: s.opts.Location.String(), Periods: []SalesPeriod{}}
+	switch groupBy {
+	case GroupByDay, GroupByWeek, GroupByMonth:
+	default:
+		return summary, invalidf("groupBy must be day, week or month")
+	}
+
+	args := []any{groupBy, summary.TimeZone}
+	conditions, args := filter.where(args)
+	query := `
+		WITH sales AS (
+			SELECT date_trunc($1, b.created_at AT TIME ZONE $2) AS period, b.id,
+			       SUM(bi.quantity * bi.unit_price) AS total
+			FROM bills b
+			JOIN bill_items bi ON bi.bill_id = b.id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += `
+			GROUP BY 1, b.id
+		)
+		SELECT to_char(period, 'YYYY-MM-DD'), COUNT(*), SUM(total), AVG(total)
+		FROM sales
+		GROUP BY period
+		ORDER BY period`
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return summary, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p SalesPeriod
+		if err := rows.Scan(&p.Start, &p.BillCount, &p.Total, &p.AverageBill); err != nil {
+			return summary, err
+		}
+		p.Total, p.AverageBill = roundFils(p.Total), roundFils(p.AverageBill)
+		summary.Totals.BillCount += p.BillCount
+		summary.Totals.Total += p.Total
+		summary.Periods = append(summary.Periods, p)
+	}
+	if err := rows.Err(); err != nil {
+		return summary, dbError(err)
+	}
+	summary.Totals.Total = roundFils(summary.Totals.Total)
+	if summary.Totals.BillCount > 0 {
+		summary.Totals.AverageBill = roundFils(summary.Totals.Total / float64(summary.Totals.BillCount))
+	}
+	return summary, nil
+}
+
+// Profit report groupings, beside GroupByDay, GroupByWeek and GroupByMonth
+const (
+	GroupByBill     = "bill"
+	GroupByItem     = "item"
+	GroupByCategory = "category"
+	GroupByCustomer = "customer"
+)
+
+// profitGroups gives the key and name of each profit grouping over the
+// lines CTE in ProfitReport.
+var profitGroups = map[string]struct{ key, name string }{
+	GroupByBill:     {"l.bill_id::text", "MAX(l.customer_name)"},
+	GroupByItem:     {"l.item_id", "MAX(l.item_name)"},
+	GroupByCategory: {"l.category_id::text", "COALESCE(MAX(p.path), '')"},
+	GroupByCustomer: {"l.customer_id::text", "COALESCE(MAX(c.name), '')"},
+	GroupByDay:      {"to_char(date_trunc('day', l.sold_at), 'YYYY-MM-DD')", "''"},
+	GroupByWeek:     {"to_char(date_trunc('week', l.sold_at), 'YYYY-MM-DD')", "''"},
+	GroupByMonth:    {"to_char(date_trunc('month', l.sold_at), 'YYYY-MM-DD')", "''"},
+}
+
+// ProfitTotals is the gross profit on a set of bill lines at the cost
+// recorded when they were sold. Lines of unknown cost count towards
+// Revenue only. MarginPercent is profit over costed revenue;
+// BaseMarginPercent is the profit on Wire/Box lines over their base price,
+// the way Wire/Box percentages are quoted.
+type ProfitTotals struct {
+	Revenue           float64  `json:"revenue"`
+	Cost              float64  `json:"cost"`
+	Profit            float64  `json:"profit"`
+	MarginPercent     *float64 `json:"marginPercent"`
+	BaseMarginPercent *float64 `json:"baseMarginPercent"`
+	LineCount         int      `json:"lineCount"`
+	BelowCostLines    int      `json:"belowCostLines"`
+	UnknownCostLines  int      `json:"unknownCostLines"`
+
+	costedRevenue, wireBoxProfit, wireBoxBase float64
+}
+
+func (t *ProfitTotals) add(o ProfitTotals) {
+	t.Revenue += o.Revenue
+	t.Cost += o.Cost
+	t.costedRevenue += o.costedRevenue
+	t.wireBoxProfit += o.wireBoxProfit
+	t.wireBoxBase += o.wireBoxBase
+	t.LineCount += o.LineCount
+	t.BelowCostLines += o.BelowCostLines
+	t.UnknownCostLines += o.UnknownCostLines
+}
+
+// finish rounds the amounts and works out profit and margins.
+func (t *ProfitTotals) finish() {
+	t.Revenue, t.Cost = roundFils(t.Revenue), roundFils(t.Cost)
+	t.Profit = roundFils(t.costedRevenue - t.Cost)
+	if t.costedRevenue != 0 {
+		m := math.Round(t.Profit/t.costedRevenue*10000) / 100
+		t.MarginPercent = &m
+	}
+	if t.wireBoxBase != 0 {
+		m := math.Round(t.wireBoxProfit/t.wireBoxBase*10000) / 100
+		t.BaseMarginPercent = &m
+	}
+}
+
+// ProfitRow is one bill, item, category, customer or period. Key is nil for
+// lines without a category or saved customer.
+type ProfitRow struct {
+	Key  *string `json:"key"`
+	Name string  `json:"name"`
+	ProfitTotals
+}
+
+// BelowCostLine is a bill line sold for less than it cost, prices per line
+// unit.
+type BelowCostLine struct {
+	BillID    string    `json:"billId"`
+	SoldAt    time.Time `json:"soldAt"`
+	ItemID    string    `json:"itemId"`
+	ItemName  string    `json:"itemName"`
+	Unit      string    `json:"unit"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unitPrice"`
+	UnitCost  float64   `json:"unitCost"`
+}
+
+type ProfitReport struct {
+	GroupBy   string          `json:"groupBy"`
+	TimeZone  string          `json:"timeZone"`
+	Totals    ProfitTotals    `json:"totals"`
+	Rows      []ProfitRow     `json:"rows"`
+	BelowCost []BelowCostLine `json:"belowCost"`
+}
+
+// profitLinesSQL selects the bill lines a profit report covers, with sold_at
+// in the business time zone ($1). Filter conditions are appended.
+const profitLinesSQL = `
+	SELECT b.id AS bill_id, b.created_at, b.created_at AT TIME ZONE $1 AS sold_at,
+	       b.customer_id, COALESCE(b.customer_name, '') AS customer_name,
+	       bi.item_id, bi.item_name, COALESCE(bi.unit, i.unit, 'pcs') AS unit, bi.quantity, bi.unit_price, bi.unit_cost, i.category_id,
+	       bi.quantity * bi.unit_price AS revenue,
+	       bi.quantity * bi.unit_cost AS cost,
+	       bi.quantity * bi.base_price AS base_value
+	FROM bills b
+	JOIN bill_items bi ON bi.bill_id = b.id
+	LEFT JOIN items i ON i.item_id = bi.item_id`
+
+// ProfitReport works out gross profit per bill, item, category, customer,
+// day, week or month from the costs recorded on bill lines, largest profit
+// first (periods in date order), and lists the lines sold below cost.
+func (s *Store) ProfitReport(ctx context.Context, filter SalesFilter, groupBy string) (ProfitReport, error) {
+	report := ProfitReport{GroupBy: groupBy, TimeZone: s.opts.Location.String(), Rows: []ProfitRow{}, BelowCost: []BelowCostLine{}}
+	group, ok := profitGroups[groupBy]
+	if !ok {
+		return report, invalidf("groupBy must be bill, item, category, customer, day, week or month")
+	}
+
+	conditions, args := filter.where([]any{report.TimeZone})
+	lines := profitLinesSQL
+	if len(conditions) > 0 {
+		lines += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	order := "profit DESC, 1"
+	switch groupBy {
+	case GroupByDay, GroupByWeek, GroupByMonth:
+		order = "1"
+	}
+
+	rows, err := s.db.Query(ctx, categoryPathsSQL+`, lines AS (`+lines+`)
+		SELECT `+group.key+`, `+group.name+`,
+		       COALESCE(SUM(l.revenue), 0),
+		       COALESCE(SUM(l.cost), 0),
+		       COALESCE(SUM(l.revenue) FILTER (WHERE l.cost IS NOT NULL), 0),
+		       COALESCE(SUM(l.revenue - l.cost) FILTER (WHERE l.base_value IS NOT NULL), 0),
+		       COALESCE(SUM(l.base_value), 0),
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE l.revenue < l.cost),
+		       COUNT(*) FILTER (WHERE l.cost IS NULL),
+		       COALESCE(SUM(l.revenue) FILTER (WHERE l.cost IS NOT NULL), 0) - COALESCE(SUM(l.cost), 0) AS profit
+		FROM lines l
+		LEFT JOIN paths p ON p.id = l.category_id
+		LEFT JOIN customers c ON c.id = l.customer_id
+		GROUP BY 1
+		ORDER BY `+order, args...)
+	if err != nil {
+		return report, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ProfitRow
+		var profit float64
+		t := &row.ProfitTotals
+		if err := rows.Scan(&row.Key, &row.Name, &t.Revenue, &t.Cost, &t.costedRevenue, &t.wireBoxProfit, &t.wireBoxBase,
+			&t.LineCount, &t.BelowCostLines, &t.UnknownCostLines, &profit); err != nil {
+			return report, err
+		}
+		report.Totals.add(row.ProfitTotals)
+		row.finish()
+		report.Rows = append(report.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return report, dbError(err)
+	}
+	rows.Close()
+	report.Totals.finish()
+
+	rows, err = s.db.Query(ctx, "WITH lines AS ("+lines+`)
+		SELECT bill_id, created_at, item_id, item_name, unit, quantity, unit_price, unit_cost
+		FROM lines
+		WHERE revenue < cost
+		ORDER BY created_at DESC, item_id`, args...)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l BelowCostLine
+		if err := rows.Scan(&l.BillID, &l.SoldAt, &l.ItemID, &l.ItemName, &l.Unit, &l.Quantity, &l.UnitPrice, &l.UnitCost); err != nil {
+			return report, err
+		}
+		l.UnitCost = roundFils(l.UnitCost)
+		report.BelowCost = append(report.BelowCost, l)
+	}
+	return report, rows.Err()
+}
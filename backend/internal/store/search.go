@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// arabicFolds mirrors the translate() table in the normalize_search SQL
+// function: alef variants, taa marbuta, yaa/alef maqsura, hamza seats,
+// Persian look-alikes and Arabic-Indic digits.
+var arabicFolds = strings.NewReplacer(
+	"أ", "ا", "إ", "ا", "آ", "ا", "ٱ", "ا",
+	"ة", "ه",
+	"ى", "ي", "ی", "ي",
+	"ک", "ك",
+	"ؤ", "و", "ئ", "ي",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4",
+	"٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+	"٫", ".",
+)
+
+// NormalizeSearch folds text the same way the items.search_text column is
+// built so that user input and stored values compare equal.
+func NormalizeSearch(input string) string {
+	folded := arabicFolds.Replace(strings.ToLower(input))
+	var b strings.Builder
+	space := false
+	for _, r := range folded {
+		switch {
+		case (r >= 0x064B && r <= 0x065F) || r == 0x0670 || r == 0x0640:
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchClause turns a free-text query into conditions and a ranking
+// expression over items, numbering placeholders after args. Every query word
+// has to appear in the item (as a substring or a close trigram match);
+// word-prefix hits rank above fuzzy ones and item ID matches rank above both.
+// It returns no conditions when the query has no words.
+func searchClause(query string, args []any) ([]string, string, []any) {
+	normalized := NormalizeSearch(query)
+	tokens := strings.Fields(normalized)
+	if len(tokens) == 0 {
+		return nil, "", args
+	}
+
+	args = append(args, normalized, likeEscaper.Replace(normalized))
+	conditions := []string{}
+	scores := []string{fmt.Sprintf("CASE WHEN lower(item_id) = $%d THEN 10 WHEN lower(item_id) LIKE $%d || '%%' THEN 2 ELSE 0 END", len(args)-1, len(args))}
+	for _, token := range tokens {
+		args = append(args, token, likeEscaper.Replace(token))
+		raw, escaped := len(args)-1, len(args)
+		conditions = append(conditions, fmt.Sprintf("(search_text LIKE '%%' || $%d || '%%' OR $%d <%% search_text)", escaped, raw))
+		scores = append(scores, fmt.Sprintf(
+			"CASE WHEN search_text LIKE $%[1]d || '%%' OR search_text LIKE '%% ' || $%[1]d || '%%' THEN 1.0 WHEN search_text LIKE '%%' || $%[1]d || '%%' THEN 0.8 ELSE word_similarity($%[2]d, search_text) END",
+			escaped, raw,
+		))
+	}
+	return conditions, "(" + strings.Join(scores, " + ") + ")", args
+}
+
+// SearchItems returns the items matching filter.Query, best match first.
+func (s *Store) SearchItems(ctx context.Context, filter ItemFilter, limit int) ([]Item, error) {
+	conditions, score, args := searchClause(filter.Query, nil)
+	if len(conditions) == 0 {
+		return []Item{}, nil
+	}
+	filterConditions, args := filter.where(args)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, limit)
+
+	sql := "SELECT " + itemColumns + " FROM items" +
+		" WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY " + score + " DESC, name" +
+		fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.attachItemCodes(ctx, items); err != nil {
+		return nil, err
+	}
+	if err := s.attachItemUnits(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, s.attachItemImages(ctx, items)
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const shareColumns = "id, bill_id, expires_at, revoked_at, created_by, last_viewed_at, view_count, created_at"
+
+func scanShare(row pgx.Row) (BillShare, error) {
+	var share BillShare
+	err := row.Scan(&share.ID, &share.BillID, &share.ExpiresAt, &share.RevokedAt, &share.CreatedBy, &share.LastViewedAt, &share.ViewCount, &share.CreatedAt)
+	return share, err
+}
+
+func (s *Store) CreateBillShare(ctx context.Context, billID string, expiresAt time.Time, createdBy string) (BillShare, error) {
+	row := s.db.QueryRow(ctx,
+		"INSERT INTO bill_shares (bill_id, expires_at, created_by) SELECT id, $2, NULLIF($3, '') FROM bills WHERE id=$1 RETURNING "+shareColumns,
+		billID, expiresAt, createdBy,
+	)
+	share, err := scanShare(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return share, ErrNotFound
+	}
+	return share, err
+}
+
+func (s *Store) ListBillShares(ctx context.Context, billID string) ([]BillShare, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+shareColumns+" FROM bill_shares WHERE bill_id=$1 ORDER BY created_at DESC", billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []BillShare{}
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (s *Store) RevokeBillShare(ctx context.Context, billID, shareID string) error {
+	cmd, err := s.db.Exec(ctx, "UPDATE bill_shares SET revoked_at=now() WHERE id=$1 AND bill_id=$2 AND revoked_at IS NULL", shareID, billID)
+	if err != nil {
+		return ErrNotFound
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UseBillShare looks up a share that is neither revoked nor expired and
+// counts the view. Anything else is reported as ErrNotFound so callers
+// cannot tell a revoked link from a made-up one.
+func (s *Store) UseBillShare(ctx context.Context, shareID string) (BillShare, error) {
+	row := s.db.QueryRow(ctx,
+		"UPDATE bill_shares SET view_count=view_count + 1, last_viewed_at=now() WHERE id=$1 AND revoked_at IS NULL AND expires_at > now() RETURNING "+shareColumns,
+		shareID,
+	)
+	share, err := scanShare(row)
+	if err != nil {
+		return share, ErrNotFound
+	}
+	return share, nil
+}
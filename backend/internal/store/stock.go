@@ -0,0 +1,318 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StockError reports a movement that would take stock below zero while
+// negative stock is not allowed.
+type StockError struct {
+	ItemID    string
+	OnHand    float64
+	Requested float64
+}
+
+func (e *StockError) Error() string {
+	return fmt.Sprintf("not enough stock for item %s: %s on hand, %s requested", e.ItemID, formatQuantity(e.OnHand), formatQuantity(e.Requested))
+}
+
+func formatQuantity(v float64) string {
+	return fmt.Sprintf("%g", roundQuantity(v))
+}
+
+// roundQuantity rounds to the ledger's four decimals.
+func roundQuantity(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// moveStock appends m to the ledger and applies it to the item's on-hand
+// total inside tx, or returns ErrNotFound for an item no longer in the
+// catalog. The stock_levels row stays locked until tx ends, so
+// concurrent movements on one item queue up behind each other.
+func (s *Store) moveStock(ctx context.Context, tx pgx.Tx, m StockMovement) error {
+	m.Quantity = roundQuantity(m.Quantity)
+	if m.Quantity == 0 {
+		return nil
+	}
+
+	// Look before writing: a failed insert would abort tx for the caller
+	err := tx.QueryRow(ctx, "SELECT item_id FROM items WHERE item_id=$1 FOR KEY SHARE", m.ItemID).Scan(&m.ItemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var onHand float64
+	err = tx.QueryRow(ctx, `
+		INSERT INTO stock_levels (item_id, on_hand) VALUES ($1, $2)
+		ON CONFLICT (item_id) DO UPDATE SET on_hand = stock_levels.on_hand + EXCLUDED.on_hand, updated_at = now()
+		RETURNING on_hand
+	`, m.ItemID, m.Quantity).Scan(&onHand)
+	if err != nil {
+		return err
+	}
+	if m.Quantity < 0 && onHand < 0 && !s.opts.AllowNegativeStock {
+		return &StockError{ItemID: m.ItemID, OnHand: onHand - m.Quantity, Requested: -m.Quantity}
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO stock_movements (item_id, kind, quantity, bill_id, purchase_invoice_id, reference, note, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))",
+		m.ItemID, m.Kind, m.Quantity, m.BillID, m.PurchaseInvoiceID, m.Reference, m.Note, derefString(m.CreatedBy),
+	)
+	return err
+}
+
+func derefString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// billStock sums a bill's lines per item in base units. Kit lines count
+// their components instead of the kit.
+func billStock(items []BillItem) map[string]float64 {
+	totals := map[string]float64{}
+	for _, item := range items {
+		factor := item.UnitFactor
+		if factor == 0 {
+			factor = 1
+		}
+		quantity := float64(item.Quantity) * factor
+		if len(item.Components) == 0 {
+			totals[item.ItemID] += quantity
+		}
+		for _, c := range item.Components {
+			totals[c.ItemID] += quantity * c.Quantity
+		}
+	}
+	return totals
+}
+
+// loadBillStock reads what a stored bill has sold per item, in base units,
+// counting kit lines as their components.
+func loadBillStock(ctx context.Context, tx pgx.Tx, billID string) (map[string]float64, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT COALESCE(c.item_id, bi.item_id), SUM(bi.quantity * bi.unit_factor * COALESCE(c.quantity, 1))
+		FROM bill_items bi
+		LEFT JOIN bill_item_components c ON c.bill_item_id = bi.id
+		WHERE bi.bill_id=$1
+		GROUP BY 1
+	`, billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[string]float64{}
+	for rows.Next() {
+		var itemID string
+		var quantity float64
+		if err := rows.Scan(&itemID, &quantity); err != nil {
+			return nil, err
+		}
+		totals[itemID] = quantity
+	}
+	return totals, rows.Err()
+}
+
+// loadBillReturns reads what has been returned against a bill per item, in
+// base units.
+func loadBillReturns(ctx context.Context, tx pgx.Tx, billID string) (map[string]float64, error) {
+	rows, err := tx.Query(ctx, "SELECT item_id, SUM(quantity) FROM stock_movements WHERE bill_id=$1 AND kind=$2 GROUP BY item_id", billID, StockReturn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[string]float64{}
+	for rows.Next() {
+		var itemID string
+		var quantity float64
+		if err := rows.Scan(&itemID, &quantity); err != nil {
+			return nil, err
+		}
+		totals[itemID] = quantity
+	}
+	return totals, rows.Err()
+}
+
+// recordSaleStock writes sale movements for the change from before to after,
+// both per-item totals in base units. Creating a bill passes a nil before,
+// deleting one a nil after. Items are visited in order so two bills touching
+// the same items lock them in the same order.
+func (s *Store) recordSaleStock(ctx context.Context, tx pgx.Tx, billID string, before, after map[string]float64, user string) error {
+	ids := make([]string, 0, len(before)+len(after))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		sold := after[id] - before[id]
+		if sold == 0 {
+			continue
+		}
+		err := s.moveStock(ctx, tx, StockMovement{ItemID: id, Kind: StockSale, Quantity: -sold, BillID: &billID, CreatedBy: &user})
+		if err != nil {
+			// An item purged from the catalog has no stock left to correct
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStock returns an item's on-hand quantity and its most recent movements.
+func (s *Store) GetStock(ctx context.Context, itemID string, limit int) (StockLevel, error) {
+	level := StockLevel{ItemID: itemID, Movements: []StockMovement{}}
+	err := s.db.QueryRow(ctx, `
+		SELECT i.unit, COALESCE(sl.on_hand, 0), sl.reorder_point, sl.reorder_quantity
+		FROM items i
+		LEFT JOIN stock_levels sl ON sl.item_id = i.item_id
+		WHERE i.item_id = $1
+	`, itemID).Scan(&level.Unit, &level.OnHand, &level.ReorderPoint, &level.ReorderQuantity)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return level, ErrNotFound
+		}
+		return level, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, item_id, kind, quantity, bill_id, purchase_invoice_id, reference, note, created_by, created_at
+		FROM stock_movements
+		WHERE item_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, itemID, limit)
+	if err != nil {
+		return level, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m StockMovement
+		if err := rows.Scan(&m.ID, &m.ItemID, &m.Kind, &m.Quantity, &m.BillID, &m.PurchaseInvoiceID, &m.Reference, &m.Note, &m.CreatedBy, &m.CreatedAt); err != nil {
+			return level, err
+		}
+		level.Movements = append(level.Movements, m)
+	}
+	return level, rows.Err()
+}
+
+// RecordStockChange books a purchase or manual adjustment given in any of
+// the item's units and returns the ledger entry.
+func (s *Store) RecordStockChange(ctx context.Context, change StockChange, user string) (StockMovement, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return StockMovement{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	m, err := s.recordStockChange(ctx, tx, change, nil, user)
+	if err != nil {
+		return m, err
+	}
+	return m, tx.Commit(ctx)
+}
+
+func (s *Store) recordStockChange(ctx context.Context, tx pgx.Tx, change StockChange, billID *string, user string) (StockMovement, error) {
+	var baseUnit string
+	var isKit bool
+	if err := tx.QueryRow(ctx, "SELECT unit, is_kit FROM items WHERE item_id=$1", change.ItemID).Scan(&baseUnit, &isKit); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return StockMovement{}, ErrNotFound
+		}
+		return StockMovement{}, err
+	}
+	if isKit {
+		return StockMovement{}, invalidf("item %s is a kit; its stock is kept on its components", change.ItemID)
+	}
+	factor, err := saleUnitFactor(ctx, tx, change.ItemID, baseUnit, change.Unit)
+	if err != nil {
+		return StockMovement{}, err
+	}
+
+	m := StockMovement{
+		ItemID:    change.ItemID,
+		Kind:      change.Kind,
+		Quantity:  roundQuantity(change.Quantity * factor),
+		BillID:    billID,
+		Reference: change.Reference,
+		Note:      change.Note,
+		CreatedBy: &user,
+	}
+	if m.Quantity == 0 {
+		return m, invalidf("quantity is too small")
+	}
+	if err := s.moveStock(ctx, tx, m); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// ReturnBillItems books customer returns against a bill. Each item must be
+// on the bill, and returns cannot add up to more than was sold. A kit comes
+// back as its components.
+func (s *Store) ReturnBillItems(ctx context.Context, billID string, changes []StockChange, user string) ([]StockMovement, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the bill so two returns cannot both pass the check below
+	var lockedID string
+	if err := tx.QueryRow(ctx, "SELECT id FROM bills WHERE id=$1 FOR UPDATE", billID).Scan(&lockedID); err != nil {
+		return nil, dbError(err)
+	}
+	sold, err := loadBillStock(ctx, tx, billID)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := []StockMovement{}
+	for _, change := range changes {
+		if _, ok := sold[change.ItemID]; !ok {
+			return nil, invalidf("item %s is not on this bill", change.ItemID)
+		}
+		change.Kind = StockReturn
+		m, err := s.recordStockChange(ctx, tx, change, &billID, user)
+		if err != nil {
+			return nil, err
+		}
+
+		var returned float64
+		if err := tx.QueryRow(ctx,
+			"SELECT COALESCE(SUM(quantity), 0) FROM stock_movements WHERE bill_id=$1 AND item_id=$2 AND kind=$3",
+			billID, change.ItemID, StockReturn,
+		).Scan(&returned); err != nil {
+			return nil, err
+		}
+		if roundQuantity(returned) > roundQuantity(sold[change.ItemID]) {
+			return nil, invalidf("item %s: returns would exceed the %s sold", change.ItemID, formatQuantity(sold[change.ItemID]))
+		}
+		movements = append(movements, m)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
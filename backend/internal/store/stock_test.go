@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// A bill can outlive the items on it, e.g. one sold before stock was kept
+// whose item has since been purged. Deleting it must still put the live
+// items back into stock.
+func TestDeleteBillWithPurgedItem(t *testing.T) {
+	s := testStore(t, Options{AllowNegativeStock: true})
+	ctx := context.Background()
+
+	purged, live := testID("A"), testID("B")
+	for _, id := range []string{purged, live} {
+		exec(t, s, "INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price, unit) VALUES ($1, $1, $1, 1, 2, 'pcs')", id)
+	}
+	var billID string
+	if err := s.db.QueryRow(ctx, "INSERT INTO bills (total_amount) VALUES (10) RETURNING id").Scan(&billID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{purged, live} {
+		exec(t, s, "INSERT INTO bill_items (bill_id, item_id, item_name, quantity, unit_price) VALUES ($1, $2, $2, 5, 1)", billID, id)
+	}
+	exec(t, s, "DELETE FROM items WHERE item_id=$1", purged)
+
+	if err := s.DeleteBill(ctx, billID, "tester"); err != nil {
+		t.Fatalf("DeleteBill: %v", err)
+	}
+	if _, err := s.GetBill(ctx, billID); err != ErrNotFound {
+		t.Errorf("GetBill after delete = %v, want ErrNotFound", err)
+	}
+	level, err := s.GetStock(ctx, live, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level.OnHand != 5 {
+		t.Errorf("on hand for %s = %g, want 5 put back", live, level.OnHand)
+	}
+}
+
+// Returns stay booked against a bill, so editing it can shrink a line to
+// what came back but not below, and later returns count the earlier ones.
+func TestUpdateBillAfterReturn(t *testing.T) {
+	s := testStore(t, Options{AllowNegativeStock: true})
+	ctx := context.Background()
+
+	item := testID("R")
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price, unit) VALUES ($1, $1, $1, 1, 2, 'pcs')", item)
+	lines := func(quantity int) BillCreate {
+		return BillCreate{Items: []BillItemCreate{{ItemID: item, Quantity: quantity}}}
+	}
+	onHand := func() float64 {
+		t.Helper()
+		level, err := s.GetStock(ctx, item, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return level.OnHand
+	}
+
+	bill, err := s.CreateBill(ctx, lines(10), "clerk")
+	if err != nil {
+		t.Fatalf("CreateBill: %v", err)
+	}
+	if _, err := s.ReturnBillItems(ctx, bill.ID, []StockChange{{ItemID: item, Quantity: 4}}, "clerk"); err != nil {
+		t.Fatalf("ReturnBillItems: %v", err)
+	}
+
+	var invalidErr *InvalidError
+	if _, err := s.UpdateBill(ctx, bill.ID, lines(3), "clerk"); !errors.As(err, &invalidErr) {
+		t.Errorf("UpdateBill below the returned quantity: got %v, want an *InvalidError", err)
+	}
+	if got := onHand(); got != -6 {
+		t.Errorf("on hand after the refused edit = %g, want -6", got)
+	}
+
+	if _, err := s.UpdateBill(ctx, bill.ID, lines(6), "clerk"); err != nil {
+		t.Fatalf("UpdateBill: %v", err)
+	}
+	// 6 sold, 4 of them back
+	if got := onHand(); got != -2 {
+		t.Errorf("on hand after shrinking to 6 = %g, want -2", got)
+	}
+	if _, err := s.ReturnBillItems(ctx, bill.ID, []StockChange{{ItemID: item, Quantity: 3}}, "clerk"); !errors.As(err, &invalidErr) {
+		t.Errorf("returning 3 more of 6 with 4 back: got %v, want an *InvalidError", err)
+	}
+	if _, err := s.ReturnBillItems(ctx, bill.ID, []StockChange{{ItemID: item, Quantity: 2}}, "clerk"); err != nil {
+		t.Errorf("returning the last 2: %v", err)
+	}
+}
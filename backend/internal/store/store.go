@@ -4,33 +4,196 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var ErrNotFound = errors.New("not found")
 
-const cleanupWindow = 24 * time.Hour
+// ErrConflict reports a write that would break a uniqueness or dependency rule.
+var ErrConflict = errors.New("conflict")
+
+// ErrInvalidReference reports a write that points at a row that does not exist.
+var ErrInvalidReference = errors.New("invalid reference")
+
+// InvalidError reports a request the store refused because of its content.
+// The message is meant for the user.
+type InvalidError struct {
+	Msg string
+}
+
+func (e *InvalidError) Error() string {
+	return e.Msg
+}
+
+func invalidf(format string, args ...any) error {
+	return &InvalidError{Msg: fmt.Sprintf(format, args...)}
+}
 
 type Store struct {
-	db *pgxpool.Pool
+	db   *pgxpool.Pool
+	opts Options
+}
+
+// Options tunes store behaviour that is set by configuration.
+type Options struct {
+	// AllowNegativeStock lets sales and adjustments take an item's on-hand
+	// quantity below zero instead of failing with a StockError.
+	AllowNegativeStock bool
+	// ItemIDPrefix and ItemIDWidth shape generated item IDs outside
+	// categories with their own prefix: ITEM and 3 give ITEM001, ITEM1000.
+	ItemIDPrefix string
+	ItemIDWidth  int
+	// CleanupWindow is how long a deleted item can be restored before it
+	// is purged or archived.
+	CleanupWindow time.Duration
+	// PriceOverrideUsers may type bill prices below an item's minimum
+	// margin; for anyone else such a line fails with a MarginError.
+	PriceOverrideUsers []string
+	// Location is the business time zone reports group days in and
+	// "today" is read in; UTC when nil.
+	Location *time.Location
+}
+
+func New(db *pgxpool.Pool, opts Options) *Store {
+	if opts.ItemIDPrefix == "" {
+		opts.ItemIDPrefix = "ITEM"
+	}
+	if opts.ItemIDWidth <= 0 {
+		opts.ItemIDWidth = 3
+	}
+	if opts.CleanupWindow <= 0 {
+		opts.CleanupWindow = 24 * time.Hour
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	return &Store{db: db, opts: opts}
+}
+
+// today returns the current date in the business time zone as YYYY-MM-DD,
+// for date columns compared against "today".
+func (s *Store) today() string {
+	return time.Now().In(s.opts.Location).Format("2006-01-02")
 }
 
-func New(db *pgxpool.Pool) *Store {
-	return &Store{db: db}
+// querier is satisfied by both the pool and a transaction, for helpers that
+// run either inside or outside one.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
-func (s *Store) ListItems(ctx context.Context, includeDeleted bool, limit, offset int) ([]Item, error) {
-	query := "SELECT item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, created_at, updated_at, deleted_at FROM items"
-	if !includeDeleted {
-		query += " WHERE deleted_at IS NULL"
+// Postgres error codes the store translates into sentinel errors.
+const (
+	pgUniqueViolation           = "23505"
+	pgForeignKeyViolation       = "23503"
+	pgInvalidTextRepresentation = "22P02"
+)
+
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// dbError maps errors from statements on caller-supplied values: no row
+// gives ErrNotFound, a unique violation ErrConflict, a foreign key violation
+// ErrInvalidReference and a malformed value, such as an id that is not a
+// UUID, an *InvalidError. The driver's message names types and columns, so
+// it is logged rather than passed on.
+func dbError(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrNotFound
+	case !errors.As(err, &pgErr):
+		return err
+	case pgErr.Code == pgUniqueViolation:
+		return ErrConflict
+	case pgErr.Code == pgForeignKeyViolation:
+		return ErrInvalidReference
+	case pgErr.Code == pgInvalidTextRepresentation:
+		log.Printf("malformed id: %v", err)
+		return invalidf("malformed id")
 	}
-	query += " ORDER BY name LIMIT $1 OFFSET $2"
+	return err
+}
+
+const itemColumns = "item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, category_id, brand_id, price_index_id, index_factor, is_kit, min_margin_percent, created_at, updated_at, deleted_at, archived_at"
 
-	rows, err := s.db.Query(ctx, query, limit, offset)
+func scanItem(row pgx.Row) (Item, error) {
+	var item Item
+	err := row.Scan(itemDest(&item)...)
+	return item, err
+}
+
+// itemDest returns the scan destinations for itemColumns, in order, for
+// queries that select more after them.
+func itemDest(item *Item) []any {
+	return []any{&item.ItemID, &item.Name, &item.ArabicName, &item.BuyingPrice, &item.SellingPrice, &item.Unit, &item.IsWireBox, &item.PurchasePercentage, &item.SellPercentage, &item.CategoryID, &item.BrandID, &item.PriceIndexID, &item.IndexFactor, &item.IsKit, &item.MinMarginPercent, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt, &item.ArchivedAt}
+}
+
+// ItemFilter narrows item listings. A category matches its whole subtree.
+// Query is free text for SearchItems and exports; where ignores it.
+type ItemFilter struct {
+	Query          string
+	IncludeDeleted bool
+	CategoryID     string
+	BrandID        string
+	IsWireBox      *bool
+	ItemIDs        []string
+	// Name matches a fragment of the English or Arabic name, unranked
+	Name string
+}
+
+// where returns the SQL conditions for the filter, numbering placeholders
+// after the args already collected.
+func (f ItemFilter) where(args []any) ([]string, []any) {
+	conditions := []string{}
+	if !f.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if f.CategoryID != "" {
+		args = append(args, f.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("category_id IN (%s)", categorySubtreeSQL(len(args))))
+	}
+	if f.BrandID != "" {
+		args = append(args, f.BrandID)
+		conditions = append(conditions, fmt.Sprintf("brand_id = $%d", len(args)))
+	}
+	if f.IsWireBox != nil {
+		args = append(args, *f.IsWireBox)
+		conditions = append(conditions, fmt.Sprintf("is_wire_box = $%d", len(args)))
+	}
+	if f.ItemIDs != nil {
+		args = append(args, f.ItemIDs)
+		conditions = append(conditions, fmt.Sprintf("item_id = ANY($%d)", len(args)))
+	}
+	if f.Name != "" {
+		args = append(args, "%"+likeEscaper.Replace(f.Name)+"%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR arabic_name ILIKE $%d)", len(args), len(args)))
+	}
+	return conditions, args
+}
+
+func (s *Store) ListItems(ctx context.Context, filter ItemFilter, limit, offset int) ([]Item, error) {
+	conditions, args := filter.where(nil)
+	query := "SELECT " + itemColumns + " FROM items"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY name LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -38,22 +201,45 @@ func (s *Store) ListItems(ctx context.Context, includeDeleted bool, limit, offse
 
 	items := []Item{}
 	for rows.Next() {
-		var item Item
-		if err := rows.Scan(&item.ItemID, &item.Name, &item.ArabicName, &item.BuyingPrice, &item.SellingPrice, &item.Unit, &item.IsWireBox, &item.PurchasePercentage, &item.SellPercentage, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt); err != nil {
+		item, err := scanItem(rows)
+		if err != nil {
 			return nil, err
 		}
 		items = append(items, item)
 	}
-	return items, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.attachItemCodes(ctx, items); err != nil {
+		return nil, err
+	}
+	if err := s.attachItemUnits(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, s.attachItemImages(ctx, items)
 }
 
+// GetItem returns an item by ID. The ID of an item merged into another
+// returns the item it was merged into.
 func (s *Store) GetItem(ctx context.Context, itemID string) (Item, error) {
-	var item Item
-	row := s.db.QueryRow(ctx, "SELECT item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, created_at, updated_at, deleted_at FROM items WHERE item_id=$1", itemID)
-	if err := row.Scan(&item.ItemID, &item.Name, &item.ArabicName, &item.BuyingPrice, &item.SellingPrice, &item.Unit, &item.IsWireBox, &item.PurchasePercentage, &item.SellPercentage, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt); err != nil {
-		return item, ErrNotFound
+	item, err := scanItem(s.db.QueryRow(ctx, "SELECT "+itemColumns+" FROM items WHERE item_id=$1", itemID))
+	if err != nil {
+		target, rerr := redirectedItemID(ctx, s.db, itemID)
+		if rerr != nil || target == "" {
+			return item, ErrNotFound
+		}
+		if item, err = scanItem(s.db.QueryRow(ctx, "SELECT "+itemColumns+" FROM items WHERE item_id=$1", target)); err != nil {
+			return item, ErrNotFound
+		}
 	}
-	return item, nil
+	if item.Codes, err = loadItemCodes(ctx, s.db, item.ItemID); err != nil {
+		return item, err
+	}
+	if item.Units, err = loadItemUnits(ctx, s.db, item.ItemID); err != nil {
+		return item, err
+	}
+	item.Images, err = loadItemImages(ctx, s.db, item.ItemID)
+	return item, err
 }
 
 func (s *Store) CreateItem(ctx context.Context, input ItemCreate) (Item, error) {
@@ -68,13 +254,28 @@ func (s *Store) CreateItem(ctx context.Context, input ItemCreate) (Item, error)
 		return item, err
 	}
 
-	if _, err := tx.Exec(ctx, "DELETE FROM items WHERE deleted_at < now() - interval '1 day'"); err != nil {
+	if err := s.purgeExpiredItems(ctx, tx); err != nil {
 		return item, err
 	}
 
+	// An empty category or brand means none, as it clears them on update
+	for _, field := range []**string{&input.CategoryID, &input.BrandID} {
+		if *field != nil && **field == "" {
+			*field = nil
+		}
+	}
+
 	itemID := strings.TrimSpace(input.ItemID)
-	if itemID == "" {
-		nextID, err := nextItemID(ctx, tx)
+	if itemID != "" {
+		target, err := redirectedItemID(ctx, tx, itemID)
+		if err != nil {
+			return item, err
+		}
+		if target != "" {
+			return item, invalidf("itemId %s was merged into %s", itemID, target)
+		}
+	} else {
+		nextID, err := s.nextItemID(ctx, tx, input.CategoryID)
 		if err != nil {
 			return item, err
 		}
@@ -86,13 +287,25 @@ func (s *Store) CreateItem(ctx context.Context, input ItemCreate) (Item, error)
 		unit = "pcs"
 	}
 	row := tx.QueryRow(ctx,
-		"INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, created_at, updated_at, deleted_at",
-		itemID, input.Name, input.ArabicName, input.BuyingPrice, input.SellingPrice, unit, input.IsWireBox, input.PurchasePercentage, input.SellPercentage,
+		"INSERT INTO items (item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, category_id, brand_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING "+itemColumns,
+		itemID, input.Name, input.ArabicName, input.BuyingPrice, input.SellingPrice, unit, input.IsWireBox, input.PurchasePercentage, input.SellPercentage, input.CategoryID, input.BrandID,
 	)
-	if err := row.Scan(&item.ItemID, &item.Name, &item.ArabicName, &item.BuyingPrice, &item.SellingPrice, &item.Unit, &item.IsWireBox, &item.PurchasePercentage, &item.SellPercentage, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt); err != nil {
+	item, err = scanItem(row)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return item, ErrInvalidReference
+		}
 		return item, err
 	}
 
+	if item.Codes, err = replaceItemCodes(ctx, tx, item.ItemID, input.Codes); err != nil {
+		return item, err
+	}
+	if item.Units, err = replaceItemUnits(ctx, tx, item.ItemID, input.Units); err != nil {
+		return item, err
+	}
+	item.Images = []ItemImage{}
+
 	if err := tx.Commit(ctx); err != nil {
 		return item, err
 	}
@@ -100,77 +313,257 @@ func (s *Store) CreateItem(ctx context.Context, input ItemCreate) (Item, error)
 	return item, nil
 }
 
-func nextItemID(ctx context.Context, tx pgx.Tx) (string, error) {
-	var nextNumber int
-	query := `
-		WITH existing AS (
-			SELECT CAST(SUBSTRING(item_id FROM 5) AS INT) AS num
-			FROM items
-			WHERE item_id LIKE 'ITEM%'
-				AND LENGTH(item_id) = 7
-				AND (deleted_at IS NULL OR deleted_at >= now() - interval '1 day')
-		),
-		maxnum AS (
-			SELECT COALESCE(MAX(num), 0) AS maxnum FROM existing
-		),
-		series AS (
-			SELECT generate_series(1, (SELECT maxnum + 1 FROM maxnum)) AS num
-		)
-		SELECT s.num
-		FROM series s
-		LEFT JOIN existing e ON e.num = s.num
-		WHERE e.num IS NULL
-		ORDER BY s.num
-		LIMIT 1;
-	`
-
-	if err := tx.QueryRow(ctx, query).Scan(&nextNumber); err != nil {
+// nextItemID hands out the next number for the item's prefix: the nearest
+// one set up the category tree, else the configured default. Sequences only
+// move forward, so IDs are never reused; numbers already taken by hand are
+// skipped.
+func (s *Store) nextItemID(ctx context.Context, tx pgx.Tx, categoryID *string) (string, error) {
+	prefix := s.opts.ItemIDPrefix
+	if categoryID != nil {
+		err := tx.QueryRow(ctx, `
+			WITH RECURSIVE up AS (
+				SELECT id, parent_id, item_id_prefix, 0 AS depth FROM categories WHERE id = $1
+				UNION ALL
+				SELECT c.id, c.parent_id, c.item_id_prefix, up.depth + 1
+				FROM categories c JOIN up ON c.id = up.parent_id
+			)
+			SELECT item_id_prefix FROM up WHERE item_id_prefix IS NOT NULL ORDER BY depth LIMIT 1
+		`, *categoryID).Scan(&prefix)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+	}
+
+	seq, err := itemIDSequence(ctx, tx, prefix)
+	if err != nil {
 		return "", err
 	}
+	for {
+		var next int64
+		if err := tx.QueryRow(ctx, "SELECT nextval($1::regclass)", seq).Scan(&next); err != nil {
+			return "", err
+		}
+		itemID := fmt.Sprintf("%s%0*d", prefix, s.opts.ItemIDWidth, next)
+		var taken bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM items WHERE item_id=$1) OR EXISTS (SELECT 1 FROM item_redirects WHERE from_item_id=$1)", itemID).Scan(&taken); err != nil {
+			return "", err
+		}
+		if !taken {
+			return itemID, nil
+		}
+	}
+}
 
-	return fmt.Sprintf("ITEM%03d", nextNumber), nil
+// itemIDSequence returns the sequence numbering prefix, creating it on first
+// use to start after the highest number in use, including IDs only left on
+// old bills or redirected by a merge.
+func itemIDSequence(ctx context.Context, tx pgx.Tx, prefix string) (string, error) {
+	seq := pgx.Identifier{"item_id_seq_" + prefix}.Sanitize()
+	exists := func() (bool, error) {
+		var found bool
+		err := tx.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", seq).Scan(&found)
+		return found, err
+	}
+	if found, err := exists(); err != nil || found {
+		return seq, err
+	}
+
+	// The first two items under a new prefix must not both create it
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", seq); err != nil {
+		return "", err
+	}
+	if found, err := exists(); err != nil || found {
+		return seq, err
+	}
+	if _, err := tx.Exec(ctx, "CREATE SEQUENCE "+seq); err != nil {
+		return "", err
+	}
+	_, err := tx.Exec(ctx, `
+		SELECT setval($2::regclass, used) FROM (
+			SELECT MAX(SUBSTRING(id FROM LENGTH($1) + 1)::BIGINT) AS used
+			FROM (SELECT item_id AS id FROM items UNION SELECT item_id FROM bill_items UNION SELECT from_item_id FROM item_redirects) ids
+			WHERE id ~ ('^' || $1 || '[0-9]{1,18}$')
+		) highest
+		WHERE used > 0
+	`, prefix, seq)
+	return seq, err
 }
 
-func (s *Store) UpdateItem(ctx context.Context, input ItemCreate) (Item, error) {
+// UpdateItem overwrites an item and records any price fields that changed
+// against changedBy.
+func (s *Store) UpdateItem(ctx context.Context, input ItemCreate, changedBy string) (Item, error) {
 	var item Item
-	row := s.db.QueryRow(ctx,
-		"UPDATE items SET name=$2, arabic_name=$3, buying_price=$4, selling_price=$5, unit=$6, is_wire_box=$7, purchase_percentage=$8, sell_percentage=$9, updated_at=now() WHERE item_id=$1 AND deleted_at IS NULL RETURNING item_id, name, arabic_name, buying_price, selling_price, unit, is_wire_box, purchase_percentage, sell_percentage, created_at, updated_at, deleted_at",
-		input.ItemID, input.Name, input.ArabicName, input.BuyingPrice, input.SellingPrice, input.Unit, input.IsWireBox, input.PurchasePercentage, input.SellPercentage,
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return item, err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockItemPrices(ctx, tx, input.ItemID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return item, ErrNotFound
+		}
+		return item, err
+	}
+
+	row := tx.QueryRow(ctx,
+		"UPDATE items SET name=$2, arabic_name=$3, buying_price=$4, selling_price=$5, unit=$6, is_wire_box=$7, purchase_percentage=$8, sell_percentage=$9, category_id=CASE WHEN $10::text IS NULL THEN category_id ELSE NULLIF($10, '')::uuid END, brand_id=CASE WHEN $11::text IS NULL THEN brand_id ELSE NULLIF($11, '')::uuid END, price_index_id=CASE WHEN $7 THEN price_index_id END, updated_at=now() WHERE item_id=$1 AND deleted_at IS NULL RETURNING "+itemColumns,
+		input.ItemID, input.Name, input.ArabicName, input.BuyingPrice, input.SellingPrice, input.Unit, input.IsWireBox, input.PurchasePercentage, input.SellPercentage, input.CategoryID, input.BrandID,
 	)
-	if err := row.Scan(&item.ItemID, &item.Name, &item.ArabicName, &item.BuyingPrice, &item.SellingPrice, &item.Unit, &item.IsWireBox, &item.PurchasePercentage, &item.SellPercentage, &item.CreatedAt, &item.UpdatedAt, &item.DeletedAt); err != nil {
+	item, err = scanItem(row)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return item, ErrInvalidReference
+		}
 		return item, ErrNotFound
 	}
+
+	if err := recordPriceChanges(ctx, tx, item.ItemID, before, pricesOf(item), changedBy); err != nil {
+		return item, err
+	}
+
+	if input.Codes != nil {
+		item.Codes, err = replaceItemCodes(ctx, tx, item.ItemID, input.Codes)
+	} else {
+		item.Codes, err = loadItemCodes(ctx, tx, item.ItemID)
+	}
+	if err != nil {
+		return item, err
+	}
+	if input.Units != nil {
+		item.Units, err = replaceItemUnits(ctx, tx, item.ItemID, input.Units)
+	} else {
+		item.Units, err = loadItemUnits(ctx, tx, item.ItemID)
+	}
+	if err != nil {
+		return item, err
+	}
+	if item.Images, err = loadItemImages(ctx, tx, item.ItemID); err != nil {
+		return item, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return item, err
+	}
 	return item, nil
 }
 
 func (s *Store) SoftDeleteItem(ctx context.Context, itemID string) error {
-	cmd, err := s.db.Exec(ctx, "UPDATE items SET deleted_at=now(), updated_at=now() WHERE item_id=$1 AND deleted_at IS NULL", itemID)
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx, "UPDATE items SET deleted_at=now(), updated_at=now() WHERE item_id=$1 AND deleted_at IS NULL", itemID)
 	if err != nil {
 		return err
 	}
 	if cmd.RowsAffected() == 0 {
 		return ErrNotFound
 	}
-	return nil
+	// Free the item's codes for reuse while it sits in the trash
+	if _, err := tx.Exec(ctx, "UPDATE item_codes SET active=FALSE WHERE item_id=$1", itemID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (s *Store) RestoreItem(ctx context.Context, itemID string) error {
-	cmd, err := s.db.Exec(ctx, "UPDATE items SET deleted_at=NULL, updated_at=now() WHERE item_id=$1 AND deleted_at >= now() - interval '1 day'", itemID)
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmd, err := tx.Exec(ctx,
+		"UPDATE items SET deleted_at=NULL, updated_at=now() WHERE item_id=$1 AND deleted_at >= $2 AND archived_at IS NULL",
+		itemID, s.cleanupCutoff(),
+	)
 	if err != nil {
 		return err
 	}
 	if cmd.RowsAffected() == 0 {
 		return ErrNotFound
 	}
-	return nil
+	if _, err := tx.Exec(ctx, "UPDATE item_codes SET active=TRUE WHERE item_id=$1", itemID); err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return ErrConflict
+		}
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-func (s *Store) CleanupDeletedItems(ctx context.Context) error {
-	_, err := s.db.Exec(ctx, "DELETE FROM items WHERE deleted_at < now() - interval '1 day'")
+// itemReferencedSQL is true while bills, stock movements, purchase invoices
+// or kits still name the item in the surrounding query's items row.
+const itemReferencedSQL = `(
+	EXISTS (SELECT 1 FROM bill_items bi WHERE bi.item_id = items.item_id)
+	OR EXISTS (SELECT 1 FROM stock_movements m WHERE m.item_id = items.item_id)
+	OR EXISTS (SELECT 1 FROM purchase_invoice_lines pl WHERE pl.item_id = items.item_id)
+	OR EXISTS (SELECT 1 FROM kit_components kc WHERE kc.component_item_id = items.item_id))`
+
+// cleanupCutoff is the deletion time before which items can no longer be
+// restored.
+func (s *Store) cleanupCutoff() time.Time {
+	return time.Now().Add(-s.opts.CleanupWindow)
+}
+
+// purgeExpiredItems empties the trash of items past the restore window.
+// Items still referenced elsewhere are archived instead of deleted.
+func (s *Store) purgeExpiredItems(ctx context.Context, q querier) error {
+	cutoff := s.cleanupCutoff()
+	if _, err := q.Exec(ctx,
+		"UPDATE items SET archived_at=now() WHERE deleted_at < $1 AND archived_at IS NULL AND "+itemReferencedSQL,
+		cutoff,
+	); err != nil {
+		return err
+	}
+	_, err := q.Exec(ctx, "DELETE FROM items WHERE deleted_at < $1 AND archived_at IS NULL", cutoff)
 	return err
 }
 
-func (s *Store) CreateBill(ctx context.Context, input BillCreate) (Bill, error) {
+func (s *Store) CleanupDeletedItems(ctx context.Context) error {
+	return s.purgeExpiredItems(ctx, s.db)
+}
+
+// ListTrash returns the deleted items that can still be restored, oldest
+// first, with when the cleanup will take them and whether it will delete or
+// archive them.
+func (s *Store) ListTrash(ctx context.Context) ([]TrashItem, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT item_id, name, arabic_name, deleted_at, `+itemReferencedSQL+`
+		FROM items
+		WHERE deleted_at IS NOT NULL AND archived_at IS NULL
+		ORDER BY deleted_at, item_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trash := []TrashItem{}
+	for rows.Next() {
+		var t TrashItem
+		var referenced bool
+		if err := rows.Scan(&t.ItemID, &t.Name, &t.ArabicName, &t.DeletedAt, &referenced); err != nil {
+			return nil, err
+		}
+		t.PurgeAt = t.DeletedAt.Add(s.opts.CleanupWindow)
+		t.Action = TrashPurge
+		if referenced {
+			t.Action = TrashArchive
+		}
+		trash = append(trash, t)
+	}
+	return trash, rows.Err()
+}
+
+// CreateBill stores a bill and takes its lines out of stock, recording the
+// movements against user. Lines priced by hand are logged as overrides and,
+// below their minimum margin, need user to be allowed to override it.
+func (s *Store) CreateBill(ctx context.Context, input BillCreate, user string) (Bill, error) {
 	bill := Bill{}
 	if len(input.Items) == 0 {
 		return bill, errors.New("bill has no items")
@@ -182,51 +575,31 @@ func (s *Store) CreateBill(ctx context.Context, input BillCreate) (Bill, error)
 	}
 	defer tx.Rollback(ctx)
 
-	items := []BillItem{}
-	var total float64
-
-	for _, line := range input.Items {
-		if line.Quantity <= 0 {
-			return bill, errors.New("quantity must be positive")
-		}
-
-		var itemID, name, arabicName string
-		var sellingPrice float64
-		var buyingPrice *float64
-		row := tx.QueryRow(ctx, "SELECT item_id, name, arabic_name, buying_price, selling_price FROM items WHERE item_id=$1 AND deleted_at IS NULL", line.ItemID)
-		if err := row.Scan(&itemID, &name, &arabicName, &buyingPrice, &sellingPrice); err != nil {
-			return bill, ErrNotFound
-		}
-
-		unitPrice := sellingPrice
-		if line.UnitPrice != nil {
-			unitPrice = *line.UnitPrice
-		}
-
-		lineTotal := unitPrice * float64(line.Quantity)
-		total += lineTotal
-
-		items = append(items, BillItem{
-			ItemID:      itemID,
-			ItemName:    name,
-			ArabicName:  arabicName,
-			Quantity:    line.Quantity,
-			BuyingPrice: buyingPrice,
-			UnitPrice:   unitPrice,
-		})
+	list, err := resolveBillCustomer(ctx, tx, &input)
+	if err != nil {
+		return bill, err
+	}
+	items, total, err := buildBillItems(ctx, tx, input.Items, list, s.today())
+	if err != nil {
+		return bill, err
 	}
 
-	row := tx.QueryRow(ctx, "INSERT INTO bills (customer_name, total_amount) VALUES ($1, $2) RETURNING id, customer_name, total_amount, created_at, updated_at", input.Customer, total)
-	if err := row.Scan(&bill.ID, &bill.Customer, &bill.TotalAmount, &bill.CreatedAt, &bill.UpdatedAt); err != nil {
+	row := tx.QueryRow(ctx,
+		"INSERT INTO bills (customer_name, customer_email, customer_id, price_list_id, branch_id, total_amount) VALUES ($1, $2, $3, $4, $5, $6) RETURNING "+billColumns,
+		input.Customer, input.CustomerEmail, input.CustomerID, input.PriceListID, input.BranchID, total,
+	)
+	if bill, err = scanBill(row); err != nil {
 		return bill, err
 	}
 
-	for i := range items {
-		row := tx.QueryRow(ctx, "INSERT INTO bill_items (bill_id, item_id, item_name, item_name_ar, quantity, unit_price) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", bill.ID, items[i].ItemID, items[i].ItemName, items[i].ArabicName, items[i].Quantity, items[i].UnitPrice)
-		if err := row.Scan(&items[i].ID); err != nil {
-			return bill, err
-		}
-		items[i].BillID = bill.ID
+	if err := insertBillItems(ctx, tx, bill.ID, items); err != nil {
+		return bill, err
+	}
+	if err := s.recordOverrides(ctx, tx, bill.ID, items, user); err != nil {
+		return bill, err
+	}
+	if err := s.recordSaleStock(ctx, tx, bill.ID, nil, billStock(items), user); err != nil {
+		return bill, err
 	}
 
 	bill.Items = items
@@ -238,14 +611,57 @@ func (s *Store) CreateBill(ctx context.Context, input BillCreate) (Bill, error)
 	return bill, nil
 }
 
-func (s *Store) ListBills(ctx context.Context, limit, offset int) ([]Bill, error) {
+const billColumns = "id, customer_name, customer_email, customer_id, price_list_id, branch_id, total_amount, created_at, updated_at"
+
+func scanBill(row pgx.Row) (Bill, error) {
+	var bill Bill
+	err := row.Scan(&bill.ID, &bill.Customer, &bill.CustomerEmail, &bill.CustomerID, &bill.PriceListID, &bill.BranchID, &bill.TotalAmount, &bill.CreatedAt, &bill.UpdatedAt)
+	return bill, err
+}
+
+// BillFilter narrows bill listings. To is exclusive; Customer matches part
+// of the customer name, ignoring case.
+type BillFilter struct {
+	From     *time.Time
+	To       *time.Time
+	Customer string
+}
+
+// where returns the SQL conditions for the filter against the bills table
+// aliased b, numbering placeholders after the args already collected.
+func (f BillFilter) where(args []any) ([]string, []any) {
+	conditions := []string{}
+	if f.From != nil {
+		args = append(args, *f.From)
+		conditions = append(conditions, fmt.Sprintf("b.created_at >= $%d", len(args)))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		conditions = append(conditions, fmt.Sprintf("b.created_at < $%d", len(args)))
+	}
+	if f.Customer != "" {
+		args = append(args, "%"+likeEscaper.Replace(f.Customer)+"%")
+		conditions = append(conditions, fmt.Sprintf("b.customer_name ILIKE $%d", len(args)))
+	}
+	return conditions, args
+}
+
+func (s *Store) ListBills(ctx context.Context, filter BillFilter, limit, offset int) ([]Bill, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 	if offset < 0 {
 		offset = 0
 	}
-	rows, err := s.db.Query(ctx, "SELECT id, customer_name, total_amount, created_at, updated_at FROM bills ORDER BY created_at DESC LIMIT $1 OFFSET $2", limit, offset)
+	conditions, args := filter.where(nil)
+	query := "SELECT " + billColumns + " FROM bills b"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY b.created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -253,8 +669,8 @@ func (s *Store) ListBills(ctx context.Context, limit, offset int) ([]Bill, error
 
 	bills := []Bill{}
 	for rows.Next() {
-		var bill Bill
-		if err := rows.Scan(&bill.ID, &bill.Customer, &bill.TotalAmount, &bill.CreatedAt, &bill.UpdatedAt); err != nil {
+		bill, err := scanBill(rows)
+		if err != nil {
 			return nil, err
 		}
 		bills = append(bills, bill)
@@ -263,17 +679,17 @@ func (s *Store) ListBills(ctx context.Context, limit, offset int) ([]Bill, error
 }
 
 func (s *Store) GetBill(ctx context.Context, billID string) (Bill, error) {
-	var bill Bill
-	row := s.db.QueryRow(ctx, "SELECT id, customer_name, total_amount, created_at, updated_at FROM bills WHERE id=$1", billID)
-	if err := row.Scan(&bill.ID, &bill.Customer, &bill.TotalAmount, &bill.CreatedAt, &bill.UpdatedAt); err != nil {
-		return bill, ErrNotFound
+	bill, err := scanBill(s.db.QueryRow(ctx, "SELECT "+billColumns+" FROM bills WHERE id=$1", billID))
+	if err != nil {
+		return bill, dbError(err)
 	}
 
 	rows, err := s.db.Query(ctx, `
 		SELECT bi.id, bi.bill_id, bi.item_id, bi.item_name,
 		       COALESCE(bi.item_name_ar, i.arabic_name, '') as item_name_ar,
-		       COALESCE(i.unit, 'pcs') as unit,
-		       bi.quantity, i.buying_price, i.purchase_percentage, i.sell_percentage, bi.unit_price
+		       COALESCE(bi.unit, i.unit, 'pcs') as unit, bi.unit_factor,
+		       bi.quantity, i.buying_price, i.purchase_percentage, i.sell_percentage, bi.unit_price, bi.unit_cost,
+		       COALESCE(bi.pricing, '[]'::jsonb), bi.promotion_id, bi.promotion_name, bi.kit_item_id
 		FROM bill_items bi
 		LEFT JOIN items i ON bi.item_id = i.item_id
 		WHERE bi.bill_id=$1 
@@ -287,16 +703,55 @@ func (s *Store) GetBill(ctx context.Context, billID string) (Bill, error) {
 	items := []BillItem{}
 	for rows.Next() {
 		var item BillItem
-		if err := rows.Scan(&item.ID, &item.BillID, &item.ItemID, &item.ItemName, &item.ArabicName, &item.Unit, &item.Quantity, &item.BuyingPrice, &item.PurchasePercentage, &item.SellPercentage, &item.UnitPrice); err != nil {
+		if err := rows.Scan(&item.ID, &item.BillID, &item.ItemID, &item.ItemName, &item.ArabicName, &item.Unit, &item.UnitFactor, &item.Quantity, &item.BuyingPrice, &item.PurchasePercentage, &item.SellPercentage, &item.UnitPrice, &item.UnitCost, &item.Pricing, &item.PromotionID, &item.PromotionName, &item.KitItemID); err != nil {
 			return bill, err
 		}
 		items = append(items, item)
 	}
+	if err := rows.Err(); err != nil {
+		return bill, err
+	}
+	rows.Close()
 	bill.Items = items
-	return bill, rows.Err()
+	return bill, attachBillItemComponents(ctx, s.db, billID, items)
 }
 
-func (s *Store) UpdateBill(ctx context.Context, billID string, input BillCreate) (Bill, error) {
+// attachBillItemComponents fills in the components of a bill's kit lines.
+func attachBillItemComponents(ctx context.Context, q querier, billID string, items []BillItem) error {
+	rows, err := q.Query(ctx, `
+		SELECT c.bill_item_id, c.item_id, c.quantity
+		FROM bill_item_components c
+		JOIN bill_items bi ON bi.id = c.bill_item_id
+		WHERE bi.bill_id = $1
+		ORDER BY c.item_id
+	`, billID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(items))
+	for i := range items {
+		index[items[i].ID] = i
+	}
+	for rows.Next() {
+		var lineID string
+		var c BillItemComponent
+		if err := rows.Scan(&lineID, &c.ItemID, &c.Quantity); err != nil {
+			return err
+		}
+		if i, ok := index[lineID]; ok {
+			items[i].Components = append(items[i].Components, c)
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateBill replaces a bill's lines and moves stock by the difference
+// between the old and new quantities. Returns stay booked against the bill,
+// so each item must still be sold at least as many times as it came back.
+// An input without a customer email or branch keeps the bill's.
+func (s *Store) UpdateBill(ctx context.Context, billID string, input BillCreate, user string) (Bill, error) {
 	bill := Bill{}
 	if len(input.Items) == 0 {
 		return bill, errors.New("bill has no items")
@@ -310,8 +765,16 @@ func (s *Store) UpdateBill(ctx context.Context, billID string, input BillCreate)
 
 	// Verify bill exists
 	var existingID string
-	if err := tx.QueryRow(ctx, "SELECT id FROM bills WHERE id=$1", billID).Scan(&existingID); err != nil {
-		return bill, ErrNotFound
+	if err := tx.QueryRow(ctx, "SELECT id FROM bills WHERE id=$1 FOR UPDATE", billID).Scan(&existingID); err != nil {
+		return bill, dbError(err)
+	}
+	sold, err := loadBillStock(ctx, tx, billID)
+	if err != nil {
+		return bill, err
+	}
+	returned, err := loadBillReturns(ctx, tx, billID)
+	if err != nil {
+		return bill, err
 	}
 
 	// Delete old bill items
@@ -320,53 +783,39 @@ func (s *Store) UpdateBill(ctx context.Context, billID string, input BillCreate)
 	}
 
 	// Build new items
-	items := []BillItem{}
-	var total float64
-
-	for _, line := range input.Items {
-		if line.Quantity <= 0 {
-			return bill, errors.New("quantity must be positive")
-		}
-
-		var itemID, name, arabicName string
-		var sellingPrice float64
-		var buyingPrice *float64
-		row := tx.QueryRow(ctx, "SELECT item_id, name, arabic_name, buying_price, selling_price FROM items WHERE item_id=$1 AND deleted_at IS NULL", line.ItemID)
-		if err := row.Scan(&itemID, &name, &arabicName, &buyingPrice, &sellingPrice); err != nil {
-			return bill, ErrNotFound
-		}
-
-		unitPrice := sellingPrice
-		if line.UnitPrice != nil {
-			unitPrice = *line.UnitPrice
+	list, err := resolveBillCustomer(ctx, tx, &input)
+	if err != nil {
+		return bill, err
+	}
+	items, total, err := buildBillItems(ctx, tx, input.Items, list, s.today())
+	if err != nil {
+		return bill, err
+	}
+	after := billStock(items)
+	for id, quantity := range returned {
+		if roundQuantity(after[id]) < roundQuantity(quantity) {
+			return bill, invalidf("item %s: %s has been returned against this bill, more than the new quantity", id, formatQuantity(quantity))
 		}
-
-		lineTotal := unitPrice * float64(line.Quantity)
-		total += lineTotal
-
-		items = append(items, BillItem{
-			ItemID:      itemID,
-			ItemName:    name,
-			ArabicName:  arabicName,
-			Quantity:    line.Quantity,
-			BuyingPrice: buyingPrice,
-			UnitPrice:   unitPrice,
-		})
 	}
 
 	// Update the bill row
-	row := tx.QueryRow(ctx, "UPDATE bills SET customer_name=$2, total_amount=$3, updated_at=now() WHERE id=$1 RETURNING id, customer_name, total_amount, created_at, updated_at", billID, input.Customer, total)
-	if err := row.Scan(&bill.ID, &bill.Customer, &bill.TotalAmount, &bill.CreatedAt, &bill.UpdatedAt); err != nil {
+	row := tx.QueryRow(ctx,
+		"UPDATE bills SET customer_name=$2, customer_email=COALESCE($3, customer_email), customer_id=$4, price_list_id=$5, branch_id=COALESCE($6, branch_id), total_amount=$7, updated_at=now() WHERE id=$1 RETURNING "+billColumns,
+		billID, input.Customer, input.CustomerEmail, input.CustomerID, input.PriceListID, input.BranchID, total,
+	)
+	if bill, err = scanBill(row); err != nil {
 		return bill, err
 	}
 
-	// Insert new bill items
-	for i := range items {
-		row := tx.QueryRow(ctx, "INSERT INTO bill_items (bill_id, item_id, item_name, item_name_ar, quantity, unit_price) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", bill.ID, items[i].ItemID, items[i].ItemName, items[i].ArabicName, items[i].Quantity, items[i].UnitPrice)
-		if err := row.Scan(&items[i].ID); err != nil {
-			return bill, err
-		}
-		items[i].BillID = bill.ID
+	// Insert new bill items and move stock by the difference
+	if err := insertBillItems(ctx, tx, bill.ID, items); err != nil {
+		return bill, err
+	}
+	if err := s.recordOverrides(ctx, tx, bill.ID, items, user); err != nil {
+		return bill, err
+	}
+	if err := s.recordSaleStock(ctx, tx, bill.ID, sold, after, user); err != nil {
+		return bill, err
 	}
 
 	bill.Items = items
@@ -378,13 +827,36 @@ func (s *Store) UpdateBill(ctx context.Context, billID string, input BillCreate)
 	return bill, nil
 }
 
-func (s *Store) DeleteBill(ctx context.Context, billID string) error {
-	cmd, err := s.db.Exec(ctx, "DELETE FROM bills WHERE id=$1", billID)
+// DeleteBill removes a bill and puts what it sold, less anything already
+// returned, back into stock.
+func (s *Store) DeleteBill(ctx context.Context, billID string, user string) error {
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	if cmd.RowsAffected() == 0 {
-		return ErrNotFound
+	defer tx.Rollback(ctx)
+
+	var existingID string
+	if err := tx.QueryRow(ctx, "SELECT id FROM bills WHERE id=$1 FOR UPDATE", billID).Scan(&existingID); err != nil {
+		return dbError(err)
+	}
+	sold, err := loadBillStock(ctx, tx, billID)
+	if err != nil {
+		return err
+	}
+	returned, err := loadBillReturns(ctx, tx, billID)
+	if err != nil {
+		return err
+	}
+	for id, quantity := range returned {
+		sold[id] -= quantity
+	}
+	if err := s.recordSaleStock(ctx, tx, billID, sold, nil, user); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM bills WHERE id=$1", billID); err != nil {
+		return err
 	}
-	return nil
+	return tx.Commit(ctx)
 }
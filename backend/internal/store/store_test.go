@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"subahan-billing-backend/internal/migrations"
+)
+
+// testStore connects to the database in TEST_DATABASE_URL, migrated to the
+// latest schema, and skips the test when it is not set. Tests share the
+// database, so they name their rows with testID.
+func testStore(t *testing.T, opts Options) *Store {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := migrations.Run(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return New(pool, opts)
+}
+
+// testID returns an ID no other test run uses.
+func testID(prefix string) string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// exec runs setup SQL, failing the test on error.
+func exec(t *testing.T, s *Store, sql string, args ...any) {
+	t.Helper()
+	if _, err := s.db.Exec(context.Background(), sql, args...); err != nil {
+		t.Fatalf("%s: %v", sql, err)
+	}
+}
+
+// Ids reach the store as caller-supplied text; one that is not a UUID must
+// come back as a bad request, not a database failure.
+func TestMalformedIDs(t *testing.T) {
+	s := testStore(t, Options{})
+	ctx := context.Background()
+
+	checks := map[string]error{
+		"DeleteSupplier":      s.DeleteSupplier(ctx, "nope"),
+		"DeleteQuantityBreak": s.DeleteQuantityBreak(ctx, "nope"),
+		"DeletePromotion":     s.DeletePromotion(ctx, "nope"),
+		"DeletePurchase":      s.DeletePurchase(ctx, "nope"),
+		"DeleteBranch":        s.DeleteBranch(ctx, "nope"),
+	}
+	_, checks["ListPurchases"] = s.ListPurchases(ctx, PurchaseFilter{SupplierID: "nope"}, 0, 0)
+	_, checks["ListPromotions"] = s.ListPromotions(ctx, PricingRuleFilter{CategoryID: "nope"})
+	_, checks["SalesSummary customer"] = s.SalesSummary(ctx, SalesFilter{CustomerID: "nope"}, GroupByDay)
+	_, checks["SalesSummary category"] = s.SalesSummary(ctx, SalesFilter{CategoryID: "nope"}, GroupByDay)
+	_, checks["ProfitReport branch"] = s.ProfitReport(ctx, SalesFilter{BranchID: "nope"}, GroupByBill)
+	_, checks["GetBill"] = s.GetBill(ctx, "nope")
+	_, checks["GetPurchase"] = s.GetPurchase(ctx, "nope")
+	for name, err := range checks {
+		var invalidErr *InvalidError
+		if !errors.As(err, &invalidErr) {
+			t.Errorf("%s: got %v, want an *InvalidError", name, err)
+		} else if err.Error() != "malformed id" {
+			t.Errorf("%s: message %q passes on the driver's", name, err)
+		}
+	}
+}
+
+// Generated item IDs start after the highest number in use for their prefix
+// and skip numbers since taken by hand.
+func TestCreateItemNumbering(t *testing.T) {
+	prefix := testID("N")
+	s := testStore(t, Options{ItemIDPrefix: prefix, ItemIDWidth: 3})
+	ctx := context.Background()
+
+	create := func() string {
+		t.Helper()
+		item, err := s.CreateItem(ctx, ItemCreate{Name: "n", ArabicName: "n", SellingPrice: 1})
+		if err != nil {
+			t.Fatalf("CreateItem: %v", err)
+		}
+		return item.ItemID
+	}
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price) VALUES ($1, 'n', 'n', 1)", prefix+"005")
+	if got, want := create(), prefix+"006"; got != want {
+		t.Errorf("first generated ID = %s, want %s", got, want)
+	}
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price) VALUES ($1, 'n', 'n', 1)", prefix+"007")
+	if got, want := create(), prefix+"008"; got != want {
+		t.Errorf("ID after one taken by hand = %s, want %s", got, want)
+	}
+}
+
+// Clients that leave customerEmail out of an edit must not lose the address
+// invoices are mailed to.
+func TestUpdateBillKeepsCustomerEmail(t *testing.T) {
+	s := testStore(t, Options{AllowNegativeStock: true})
+	ctx := context.Background()
+
+	item := testID("E")
+	exec(t, s, "INSERT INTO items (item_id, name, arabic_name, selling_price) VALUES ($1, $1, $1, 2)", item)
+	email := "buyer@example.com"
+	lines := []BillItemCreate{{ItemID: item, Quantity: 1}}
+	bill, err := s.CreateBill(ctx, BillCreate{CustomerEmail: &email, Items: lines}, "clerk")
+	if err != nil {
+		t.Fatalf("CreateBill: %v", err)
+	}
+
+	updated, err := s.UpdateBill(ctx, bill.ID, BillCreate{Items: lines}, "clerk")
+	if err != nil {
+		t.Fatalf("UpdateBill: %v", err)
+	}
+	if updated.CustomerEmail == nil || *updated.CustomerEmail != email {
+		t.Errorf("customerEmail after an edit without one = %v, want %s", updated.CustomerEmail, email)
+	}
+}
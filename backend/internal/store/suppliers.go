@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const supplierColumns = "id, name, email, phone, notes, created_at, updated_at"
+
+func scanSupplier(row pgx.Row) (Supplier, error) {
+	var sp Supplier
+	err := row.Scan(&sp.ID, &sp.Name, &sp.Email, &sp.Phone, &sp.Notes, &sp.CreatedAt, &sp.UpdatedAt)
+	return sp, err
+}
+
+func (s *Store) ListSuppliers(ctx context.Context) ([]Supplier, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+supplierColumns+" FROM suppliers ORDER BY lower(name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []Supplier{}
+	for rows.Next() {
+		sp, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, sp)
+	}
+	return suppliers, rows.Err()
+}
+
+func (s *Store) GetSupplier(ctx context.Context, id string) (Supplier, error) {
+	sp, err := scanSupplier(s.db.QueryRow(ctx, "SELECT "+supplierColumns+" FROM suppliers WHERE id=$1", id))
+	if err != nil {
+		return sp, ErrNotFound
+	}
+	return sp, nil
+}
+
+func (s *Store) CreateSupplier(ctx context.Context, input SupplierInput) (Supplier, error) {
+	sp, err := scanSupplier(s.db.QueryRow(ctx,
+		"INSERT INTO suppliers (name, email, phone, notes) VALUES ($1, $2, $3, $4) RETURNING "+supplierColumns,
+		strings.TrimSpace(input.Name), input.Email, input.Phone, input.Notes,
+	))
+	return sp, dbError(err)
+}
+
+func (s *Store) UpdateSupplier(ctx context.Context, id string, input SupplierInput) (Supplier, error) {
+	sp, err := scanSupplier(s.db.QueryRow(ctx,
+		"UPDATE suppliers SET name=$2, email=$3, phone=$4, notes=$5, updated_at=now() WHERE id=$1 RETURNING "+supplierColumns,
+		id, strings.TrimSpace(input.Name), input.Email, input.Phone, input.Notes,
+	))
+	return sp, dbError(err)
+}
+
+// DeleteSupplier removes a supplier with no purchase invoices; otherwise it
+// returns ErrConflict.
+func (s *Store) DeleteSupplier(ctx context.Context, id string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM suppliers WHERE id=$1", id)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return ErrConflict
+		}
+		return dbError(err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -1,20 +1,42 @@
 package store
 
-import "time"
+import (
+	"time"
+
+	"subahan-billing-backend/internal/pricing"
+)
 
 type Item struct {
-	ItemID             string     `json:"itemId"`
-	Name               string     `json:"name"`
-	ArabicName         string     `json:"arabicName"`
-	BuyingPrice        *float64   `json:"buyingPrice"`
-	SellingPrice       float64    `json:"sellingPrice"`
-	Unit               string     `json:"unit"`
-	IsWireBox          bool       `json:"isWireBox"`
-	PurchasePercentage *float64   `json:"purchasePercentage"`
-	SellPercentage     *float64   `json:"sellPercentage"`
-	CreatedAt          time.Time  `json:"createdAt"`
-	UpdatedAt          time.Time  `json:"updatedAt"`
-	DeletedAt          *time.Time `json:"deletedAt"`
+	ItemID             string   `json:"itemId"`
+	Name               string   `json:"name"`
+	ArabicName         string   `json:"arabicName"`
+	BuyingPrice        *float64 `json:"buyingPrice"`
+	SellingPrice       float64  `json:"sellingPrice"`
+	Unit               string   `json:"unit"`
+	IsWireBox          bool     `json:"isWireBox"`
+	PurchasePercentage *float64 `json:"purchasePercentage"`
+	SellPercentage     *float64 `json:"sellPercentage"`
+	CategoryID         *string  `json:"categoryId"`
+	BrandID            *string  `json:"brandId"`
+	// PriceIndexID links a Wire/Box item's base price to an index:
+	// buyingPrice = latest index value × IndexFactor
+	PriceIndexID *string  `json:"priceIndexId"`
+	IndexFactor  *float64 `json:"indexFactor"`
+	// IsKit marks an item sold as a set of components; see KitComponent
+	IsKit bool `json:"isKit"`
+	// MinMarginPercent guards hand-typed bill prices; when nil the item's
+	// nearest category with one applies
+	MinMarginPercent *float64   `json:"minMarginPercent"`
+	Codes            []ItemCode `json:"codes"`
+	Units            []ItemUnit `json:"units"`
+	// Images are in display order; the first is the item's main photo
+	Images    []ItemImage `json:"images"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	DeletedAt *time.Time  `json:"deletedAt"`
+	// ArchivedAt is set once a deleted item is past restoring but kept
+	// because bills, stock or purchases still name it
+	ArchivedAt *time.Time `json:"archivedAt"`
 }
 
 type ItemCreate struct {
@@ -27,15 +49,64 @@ type ItemCreate struct {
 	IsWireBox          bool     `json:"isWireBox"`
 	PurchasePercentage *float64 `json:"purchasePercentage"`
 	SellPercentage     *float64 `json:"sellPercentage"`
+	// CategoryID and BrandID left out of an update keep the item's own; an
+	// empty string clears them.
+	CategoryID *string `json:"categoryId"`
+	BrandID    *string `json:"brandId"`
+	// Codes replaces the item's barcodes and SKUs. On update, omitting it
+	// leaves the existing codes alone.
+	Codes []ItemCode `json:"codes"`
+	// Units replaces the extra units the item can be sold in; omitted on
+	// update means leave them alone.
+	Units []ItemUnit `json:"units"`
+}
+
+type Unit struct {
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	ArabicName string    `json:"arabicName"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type UnitInput struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	ArabicName string `json:"arabicName"`
+}
+
+// ItemUnit is a unit an item can be sold in besides its base unit. Factor is
+// how many base units one of it holds, e.g. 100 for a 100 m roll of cable
+// kept in metres.
+type ItemUnit struct {
+	Unit   string  `json:"unit"`
+	Factor float64 `json:"factor"`
+}
+
+// Item code kinds
+const (
+	CodeKindEAN13   = "ean13"
+	CodeKindCode128 = "code128"
+	CodeKindSKU     = "sku"
+)
+
+type ItemCode struct {
+	Code     string  `json:"code"`
+	Kind     string  `json:"kind"`
+	Supplier *string `json:"supplier,omitempty"`
 }
 
 type Bill struct {
-	ID          string     `json:"id"`
-	Customer    *string    `json:"customer"`
-	TotalAmount float64    `json:"totalAmount"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	Items       []BillItem `json:"items"`
+	ID            string     `json:"id"`
+	Customer      *string    `json:"customer"`
+	CustomerEmail *string    `json:"customerEmail"`
+	CustomerID    *string    `json:"customerId"`
+	PriceListID   *string    `json:"priceListId"`
+	BranchID      *string    `json:"branchId"`
+	TotalAmount   float64    `json:"totalAmount"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	Items         []BillItem `json:"items"`
 }
 
 type BillItem struct {
@@ -45,20 +116,573 @@ type BillItem struct {
 	ItemName           string   `json:"itemName"`
 	ArabicName         string   `json:"arabicName"`
 	Unit               string   `json:"unit"`
+	UnitFactor         float64  `json:"unitFactor"`
 	Quantity           int      `json:"quantity"`
 	BuyingPrice        *float64 `json:"buyingPrice"`
 	PurchasePercentage *float64 `json:"purchasePercentage"`
 	SellPercentage     *float64 `json:"sellPercentage"`
 	UnitPrice          float64  `json:"unitPrice"`
+	// UnitCost is what one line unit cost when sold, if known
+	UnitCost *float64 `json:"unitCost"`
+	// Pricing lists the rules that produced UnitPrice, in order
+	Pricing []pricing.Applied `json:"pricing"`
+	// PromotionID and PromotionName name the promotion the line was sold
+	// under, if any
+	PromotionID   *string `json:"promotionId"`
+	PromotionName *string `json:"promotionName"`
+	// KitItemID is set on lines billed as the components of a kit
+	KitItemID *string `json:"kitItemId"`
+	// Components are what a kit line took out of stock
+	Components []BillItemComponent `json:"components,omitempty"`
+
+	override *lineOverride
+	// basePrice is the Wire/Box base price per line unit when sold
+	basePrice *float64
+}
+
+// BillItemComponent is an item a kit line took out of stock, in its base
+// unit per base unit of the kit.
+type BillItemComponent struct {
+	ItemID   string  `json:"itemId"`
+	Quantity float64 `json:"quantity"`
 }
 
 type BillItemCreate struct {
-	ItemID    string   `json:"itemId"`
-	Quantity  int      `json:"quantity"`
+	ItemID   string `json:"itemId"`
+	Quantity int    `json:"quantity"`
+	// Unit defaults to the item's base unit
+	Unit      string   `json:"unit"`
 	UnitPrice *float64 `json:"unitPrice"`
+	// ExpandKit bills a kit as one line per component, each priced on its
+	// own, instead of one line at the kit's price
+	ExpandKit bool `json:"expandKit"`
 }
 
 type BillCreate struct {
-	Customer *string          `json:"customer"`
+	Customer      *string `json:"customer"`
+	CustomerEmail *string `json:"customerEmail"`
+	// CustomerID links a saved customer, whose name, email and price list
+	// fill in anything the bill leaves out
+	CustomerID *string `json:"customerId"`
+	// PriceListID prices lines without a unitPrice, overriding the customer's list
+	PriceListID *string `json:"priceListId"`
+	// BranchID is the shop the bill is rung up at; an update without one
+	// keeps the bill's branch
+	BranchID *string          `json:"branchId"`
 	Items    []BillItemCreate `json:"items"`
 }
+
+type BillDelivery struct {
+	ID            string            `json:"id"`
+	BillID        string            `json:"billId"`
+	Recipient     string            `json:"recipient"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	LastError     *string           `json:"lastError"`
+	NextAttemptAt *time.Time        `json:"nextAttemptAt"`
+	SentAt        *time.Time        `json:"sentAt"`
+	RequestedBy   *string           `json:"requestedBy"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+	History       []DeliveryAttempt `json:"history,omitempty"`
+}
+
+type DeliveryAttempt struct {
+	ID          string    `json:"id"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+	Succeeded   bool      `json:"succeeded"`
+	Error       *string   `json:"error"`
+}
+
+type BillShare struct {
+	ID           string     `json:"id"`
+	BillID       string     `json:"billId"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	CreatedBy    *string    `json:"createdBy"`
+	LastViewedAt *time.Time `json:"lastViewedAt"`
+	ViewCount    int        `json:"viewCount"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type PriceIndex struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Unit describes the value, e.g. USD/t
+	Unit            string    `json:"unit"`
+	LatestValue     *float64  `json:"latestValue"`
+	LatestDate      *string   `json:"latestDate"`
+	LinkedItemCount int       `json:"linkedItemCount"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type PriceIndexInput struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+type PriceIndexValue struct {
+	ID            int64     `json:"id"`
+	PriceIndexID  string    `json:"priceIndexId"`
+	EffectiveDate string    `json:"effectiveDate"`
+	Value         float64   `json:"value"`
+	PostedBy      *string   `json:"postedBy"`
+	PostedAt      time.Time `json:"postedAt"`
+}
+
+// IndexReprice is the outcome of posting an index value: the items whose
+// prices moved. Values dated before the latest one are kept for the record
+// but reprice nothing.
+type IndexReprice struct {
+	Value   PriceIndexValue `json:"value"`
+	Applied bool            `json:"applied"`
+	Items   []RepriceResult `json:"items"`
+}
+
+// KitComponent is one item in a kit: Quantity of Unit per kit, where an
+// empty Unit is the component's base unit.
+type KitComponent struct {
+	ItemID   string `json:"itemId"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Unit     string `json:"unit"`
+}
+
+// QuantityBreak lowers the price from MinQuantity base units on, for one
+// item or for every item under a category. Category breaks are discounts.
+type QuantityBreak struct {
+	ID              string    `json:"id"`
+	ItemID          *string   `json:"itemId"`
+	CategoryID      *string   `json:"categoryId"`
+	MinQuantity     float64   `json:"minQuantity"`
+	Price           *float64  `json:"price"`
+	DiscountPercent *float64  `json:"discountPercent"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type QuantityBreakInput struct {
+	ItemID          *string  `json:"itemId"`
+	CategoryID      *string  `json:"categoryId"`
+	MinQuantity     float64  `json:"minQuantity"`
+	Price           *float64 `json:"price"`
+	DiscountPercent *float64 `json:"discountPercent"`
+}
+
+// Promotion is a price that runs from StartsOn to EndsOn inclusive, for one
+// item or for every item under a category. Category promotions are
+// discounts.
+type Promotion struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	ArabicName      string    `json:"arabicName"`
+	ItemID          *string   `json:"itemId"`
+	CategoryID      *string   `json:"categoryId"`
+	Price           *float64  `json:"price"`
+	DiscountPercent *float64  `json:"discountPercent"`
+	StartsOn        string    `json:"startsOn"`
+	EndsOn          string    `json:"endsOn"`
+	Running         bool      `json:"running"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+type PromotionInput struct {
+	Name            string   `json:"name"`
+	ArabicName      string   `json:"arabicName"`
+	ItemID          *string  `json:"itemId"`
+	CategoryID      *string  `json:"categoryId"`
+	Price           *float64 `json:"price"`
+	DiscountPercent *float64 `json:"discountPercent"`
+	StartsOn        string   `json:"startsOn"`
+	EndsOn          string   `json:"endsOn"`
+}
+
+// PricingRuleFilter narrows quantity breaks and promotions to one item or
+// category. Running keeps only promotions on today in the business time zone.
+type PricingRuleFilter struct {
+	ItemID     string
+	CategoryID string
+	Running    bool
+}
+
+// PriceIndexLink ties an item to an index. A nil Factor keeps the item's
+// current base price by deriving the factor from the latest value.
+type PriceIndexLink struct {
+	PriceIndexID *string  `json:"priceIndexId"`
+	Factor       *float64 `json:"factor"`
+}
+
+// ItemImage describes an uploaded photo. The files themselves are in media
+// storage.
+type ItemImage struct {
+	ID          string    `json:"id"`
+	ItemID      string    `json:"itemId"`
+	ContentType string    `json:"contentType"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`
+	Position    int       `json:"position"`
+	CreatedBy   *string   `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ItemMergeSource is what one merged item handed over to the target.
+type ItemMergeSource struct {
+	ItemID         string `json:"itemId"`
+	Name           string `json:"name"`
+	BillLines      int64  `json:"billLines"`
+	StockMovements int64  `json:"stockMovements"`
+	PriceChanges   int64  `json:"priceChanges"`
+}
+
+type ItemMerge struct {
+	Target   Item              `json:"target"`
+	Sources  []ItemMergeSource `json:"sources"`
+	MergedBy *string           `json:"mergedBy"`
+	MergedAt time.Time         `json:"mergedAt"`
+}
+
+// Trash actions: what the cleanup will do with a deleted item once its
+// restore window ends
+const (
+	TrashPurge   = "purge"
+	TrashArchive = "archive"
+)
+
+type TrashItem struct {
+	ItemID     string    `json:"itemId"`
+	Name       string    `json:"name"`
+	ArabicName string    `json:"arabicName"`
+	DeletedAt  time.Time `json:"deletedAt"`
+	PurgeAt    time.Time `json:"purgeAt"`
+	Action     string    `json:"action"`
+}
+
+type Category struct {
+	ID         string  `json:"id"`
+	ParentID   *string `json:"parentId"`
+	Name       string  `json:"name"`
+	ArabicName string  `json:"arabicName"`
+	Path       string  `json:"path"`
+	ArabicPath string  `json:"arabicPath"`
+	Depth      int     `json:"depth"`
+	ItemCount  int     `json:"itemCount"`
+	// ItemIDPrefix numbers new items in this branch, e.g. CAB for CAB001
+	ItemIDPrefix *string `json:"itemIdPrefix"`
+	// MinMarginPercent applies to items in this branch without their own
+	MinMarginPercent *float64  `json:"minMarginPercent"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type CategoryInput struct {
+	ParentID   *string `json:"parentId"`
+	Name       string  `json:"name"`
+	ArabicName string  `json:"arabicName"`
+	// ItemIDPrefix and MinMarginPercent left out of an update keep the
+	// category's own. An empty prefix clears it; SetCategoryMinMargin
+	// clears the margin.
+	ItemIDPrefix     *string  `json:"itemIdPrefix"`
+	MinMarginPercent *float64 `json:"minMarginPercent"`
+}
+
+type Brand struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	ArabicName string    `json:"arabicName"`
+	ItemCount  int       `json:"itemCount"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type BrandInput struct {
+	Name       string `json:"name"`
+	ArabicName string `json:"arabicName"`
+}
+
+// ItemImportRow is one validated row of a catalog import; Row is its line
+// number in the source file.
+type ItemImportRow struct {
+	Row  int
+	Item ItemCreate
+}
+
+type ItemImportResult struct {
+	Row    int    `json:"row"`
+	ItemID string `json:"itemId"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ItemPrices is the subset of an item that the price history tracks.
+type ItemPrices struct {
+	BuyingPrice        *float64 `json:"buyingPrice"`
+	SellingPrice       float64  `json:"sellingPrice"`
+	PurchasePercentage *float64 `json:"purchasePercentage"`
+	SellPercentage     *float64 `json:"sellPercentage"`
+}
+
+// RepriceResult is one line of a bulk repricing diff. Skipped explains why a
+// matched item was left alone.
+type RepriceResult struct {
+	ItemID     string     `json:"itemId"`
+	Name       string     `json:"name"`
+	ArabicName string     `json:"arabicName"`
+	IsWireBox  bool       `json:"isWireBox"`
+	Before     ItemPrices `json:"before"`
+	After      ItemPrices `json:"after"`
+	Skipped    string     `json:"skipped,omitempty"`
+}
+
+// Price fields tracked in the price history
+const (
+	PriceFieldBuying          = "buyingPrice"
+	PriceFieldSelling         = "sellingPrice"
+	PriceFieldPurchasePercent = "purchasePercentage"
+	PriceFieldSellPercent     = "sellPercentage"
+)
+
+type PriceChange struct {
+	ID        int64     `json:"id"`
+	ItemID    string    `json:"itemId"`
+	Field     string    `json:"field"`
+	OldValue  *float64  `json:"oldValue"`
+	NewValue  *float64  `json:"newValue"`
+	ChangedBy *string   `json:"changedBy"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// PriceMovement summarises how one price field of an item moved over a
+// period: its value before the first change and after the last.
+type PriceMovement struct {
+	ItemID        string    `json:"itemId"`
+	Name          string    `json:"name"`
+	ArabicName    string    `json:"arabicName"`
+	Field         string    `json:"field"`
+	From          *float64  `json:"from"`
+	To            *float64  `json:"to"`
+	Changes       int       `json:"changes"`
+	LastChangedAt time.Time `json:"lastChangedAt"`
+}
+
+type PriceList struct {
+	ID                string    `json:"id"`
+	Name              string    `json:"name"`
+	ArabicName        string    `json:"arabicName"`
+	AdjustmentPercent float64   `json:"adjustmentPercent"`
+	SellPercentage    *float64  `json:"sellPercentage"`
+	ItemCount         int       `json:"itemCount"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type PriceListInput struct {
+	Name              string   `json:"name"`
+	ArabicName        string   `json:"arabicName"`
+	AdjustmentPercent float64  `json:"adjustmentPercent"`
+	SellPercentage    *float64 `json:"sellPercentage"`
+}
+
+// PriceListItem overrides a list's rule for one item: a fixed price per base
+// unit, or for Wire/Box items a sell percentage.
+type PriceListItem struct {
+	ItemID         string   `json:"itemId"`
+	Name           string   `json:"name"`
+	Price          *float64 `json:"price"`
+	SellPercentage *float64 `json:"sellPercentage"`
+}
+
+type Customer struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Email       *string   `json:"email"`
+	Phone       *string   `json:"phone"`
+	PriceListID *string   `json:"priceListId"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type CustomerInput struct {
+	Name        string  `json:"name"`
+	Email       *string `json:"email"`
+	Phone       *string `json:"phone"`
+	PriceListID *string `json:"priceListId"`
+}
+
+// Stock movement kinds
+const (
+	StockSale       = "sale"
+	StockReturn     = "return"
+	StockPurchase   = "purchase"
+	StockAdjustment = "adjustment"
+)
+
+// StockMovement is one ledger entry. Quantity is in the item's base unit:
+// positive adds stock, negative removes it.
+type StockMovement struct {
+	ID       int64   `json:"id"`
+	ItemID   string  `json:"itemId"`
+	Kind     string  `json:"kind"`
+	Quantity float64 `json:"quantity"`
+	BillID   *string `json:"billId"`
+	// PurchaseInvoiceID is set on movements booked by posting a purchase
+	PurchaseInvoiceID *string   `json:"purchaseInvoiceId"`
+	Reference         *string   `json:"reference"`
+	Note              *string   `json:"note"`
+	CreatedBy         *string   `json:"createdBy"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+type StockLevel struct {
+	ItemID          string          `json:"itemId"`
+	Unit            string          `json:"unit"`
+	OnHand          float64         `json:"onHand"`
+	ReorderPoint    *float64        `json:"reorderPoint"`
+	ReorderQuantity *float64        `json:"reorderQuantity"`
+	Movements       []StockMovement `json:"movements"`
+}
+
+// ReorderSettings are in the item's base unit. A nil ReorderPoint leaves the
+// reorder job to derive one from recent sales.
+type ReorderSettings struct {
+	ReorderPoint    *float64 `json:"reorderPoint"`
+	ReorderQuantity *float64 `json:"reorderQuantity"`
+}
+
+// ReorderSuggestion is one line of the low-stock list, in base units.
+type ReorderSuggestion struct {
+	ItemID            string    `json:"itemId"`
+	Name              string    `json:"name"`
+	ArabicName        string    `json:"arabicName"`
+	Unit              string    `json:"unit"`
+	OnHand            float64   `json:"onHand"`
+	AvgDailySales     float64   `json:"avgDailySales"`
+	ReorderPoint      float64   `json:"reorderPoint"`
+	SuggestedQuantity float64   `json:"suggestedQuantity"`
+	DaysOfCover       *float64  `json:"daysOfCover"`
+	ComputedAt        time.Time `json:"computedAt"`
+}
+
+// StockChange is a requested movement expressed in any of the item's units.
+type StockChange struct {
+	ItemID    string  `json:"itemId"`
+	Kind      string  `json:"kind"`
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit"`
+	Reference *string `json:"reference"`
+	Note      *string `json:"note"`
+}
+
+type Supplier struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Email     *string   `json:"email"`
+	Phone     *string   `json:"phone"`
+	Notes     *string   `json:"notes"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type SupplierInput struct {
+	Name  string  `json:"name"`
+	Email *string `json:"email"`
+	Phone *string `json:"phone"`
+	Notes *string `json:"notes"`
+}
+
+// Branch is a shop bills are rung up at.
+type Branch struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type BranchInput struct {
+	Name string `json:"name"`
+}
+
+// Purchase invoice statuses
+const (
+	PurchaseDraft  = "draft"
+	PurchasePosted = "posted"
+)
+
+type PurchaseInvoice struct {
+	ID            string         `json:"id"`
+	SupplierID    string         `json:"supplierId"`
+	SupplierName  string         `json:"supplierName"`
+	InvoiceNumber *string        `json:"invoiceNumber"`
+	InvoiceDate   string         `json:"invoiceDate"`
+	Status        string         `json:"status"`
+	ExtraCosts    float64        `json:"extraCosts"`
+	Subtotal      float64        `json:"subtotal"`
+	TotalAmount   float64        `json:"totalAmount"`
+	UpdatePrices  bool           `json:"updatePrices"`
+	Note          *string        `json:"note"`
+	CreatedBy     *string        `json:"createdBy"`
+	PostedBy      *string        `json:"postedBy"`
+	PostedAt      *time.Time     `json:"postedAt"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+	Lines         []PurchaseLine `json:"lines,omitempty"`
+}
+
+type PurchaseLine struct {
+	ID             string   `json:"id"`
+	ItemID         string   `json:"itemId"`
+	ItemName       string   `json:"itemName"`
+	Quantity       float64  `json:"quantity"`
+	Unit           string   `json:"unit"`
+	UnitFactor     float64  `json:"unitFactor"`
+	UnitCost       float64  `json:"unitCost"`
+	LineTotal      float64  `json:"lineTotal"`
+	LandedUnitCost *float64 `json:"landedUnitCost"`
+}
+
+type PurchaseInvoiceInput struct {
+	SupplierID    string  `json:"supplierId"`
+	InvoiceNumber *string `json:"invoiceNumber"`
+	// InvoiceDate is YYYY-MM-DD; empty means today
+	InvoiceDate string  `json:"invoiceDate"`
+	ExtraCosts  float64 `json:"extraCosts"`
+	// UpdatePrices makes posting write the landed cost back to the items
+	UpdatePrices bool                `json:"updatePrices"`
+	Note         *string             `json:"note"`
+	Lines        []PurchaseLineInput `json:"lines"`
+}
+
+type PurchaseLineInput struct {
+	ItemID   string  `json:"itemId"`
+	Quantity float64 `json:"quantity"`
+	// Unit defaults to the item's base unit; UnitCost is per Unit
+	Unit     string  `json:"unit"`
+	UnitCost float64 `json:"unitCost"`
+}
+
+type PurchaseFilter struct {
+	SupplierID string
+	Status     string
+	From       *time.Time
+	To         *time.Time
+}
+
+// PriceOverride is a bill line priced by hand instead of from the catalog.
+// Prices are per line unit; MinimumPrice is nil when the item has no
+// minimum margin or no known cost.
+type PriceOverride struct {
+	ID            int64     `json:"id"`
+	BillID        *string   `json:"billId"`
+	ItemID        string    `json:"itemId"`
+	ItemName      string    `json:"itemName"`
+	Unit          string    `json:"unit"`
+	Quantity      int       `json:"quantity"`
+	CatalogPrice  float64   `json:"catalogPrice"`
+	OverridePrice float64   `json:"overridePrice"`
+	MinimumPrice  *float64  `json:"minimumPrice"`
+	BelowMargin   bool      `json:"belowMargin"`
+	CreatedBy     *string   `json:"createdBy"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
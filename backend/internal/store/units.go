@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const unitColumns = "code, name, arabic_name, created_at, updated_at"
+
+func scanUnit(row pgx.Row) (Unit, error) {
+	var u Unit
+	err := row.Scan(&u.Code, &u.Name, &u.ArabicName, &u.CreatedAt, &u.UpdatedAt)
+	return u, err
+}
+
+func (s *Store) ListUnits(ctx context.Context) ([]Unit, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+unitColumns+" FROM units ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []Unit{}
+	for rows.Next() {
+		u, err := scanUnit(rows)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (s *Store) CreateUnit(ctx context.Context, input UnitInput) (Unit, error) {
+	u, err := scanUnit(s.db.QueryRow(ctx,
+		"INSERT INTO units (code, name, arabic_name) VALUES ($1, $2, $3) RETURNING "+unitColumns,
+		strings.TrimSpace(input.Code), strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName),
+	))
+	if isPgError(err, pgUniqueViolation) {
+		return u, ErrConflict
+	}
+	return u, err
+}
+
+// UpdateUnit renames a unit's labels; the code itself is fixed once created.
+func (s *Store) UpdateUnit(ctx context.Context, code string, input UnitInput) (Unit, error) {
+	u, err := scanUnit(s.db.QueryRow(ctx,
+		"UPDATE units SET name=$2, arabic_name=$3, updated_at=now() WHERE code=$1 RETURNING "+unitColumns,
+		code, strings.TrimSpace(input.Name), strings.TrimSpace(input.ArabicName),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return u, ErrNotFound
+	}
+	return u, err
+}
+
+// DeleteUnit removes a unit no item uses; otherwise it returns ErrConflict.
+func (s *Store) DeleteUnit(ctx context.Context, code string) error {
+	cmd, err := s.db.Exec(ctx, "DELETE FROM units WHERE code=$1", code)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return ErrConflict
+		}
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// replaceItemUnits swaps the item's extra sale units inside tx. An unknown
+// unit yields ErrInvalidReference.
+func replaceItemUnits(ctx context.Context, tx pgx.Tx, itemID string, units []ItemUnit) ([]ItemUnit, error) {
+	if _, err := tx.Exec(ctx, "DELETE FROM item_units WHERE item_id=$1", itemID); err != nil {
+		return nil, err
+	}
+	for _, u := range units {
+		if _, err := tx.Exec(ctx, "INSERT INTO item_units (item_id, unit, factor) VALUES ($1, $2, $3)", itemID, u.Unit, u.Factor); err != nil {
+			if isPgError(err, pgForeignKeyViolation) {
+				return nil, ErrInvalidReference
+			}
+			return nil, err
+		}
+	}
+	return loadItemUnits(ctx, tx, itemID)
+}
+
+func loadItemUnits(ctx context.Context, q querier, itemID string) ([]ItemUnit, error) {
+	rows, err := q.Query(ctx, "SELECT unit, factor FROM item_units WHERE item_id=$1 ORDER BY factor, unit", itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []ItemUnit{}
+	for rows.Next() {
+		var u ItemUnit
+		if err := rows.Scan(&u.Unit, &u.Factor); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+// attachItemUnits fills in Units for a page of items with a single query.
+func (s *Store) attachItemUnits(ctx context.Context, items []Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]string, len(items))
+	index := make(map[string]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ItemID
+		index[items[i].ItemID] = i
+		items[i].Units = []ItemUnit{}
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT item_id, unit, factor FROM item_units WHERE item_id = ANY($1) ORDER BY factor, unit", ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID string
+		var u ItemUnit
+		if err := rows.Scan(&itemID, &u.Unit, &u.Factor); err != nil {
+			return err
+		}
+		if i, ok := index[itemID]; ok {
+			items[i].Units = append(items[i].Units, u)
+		}
+	}
+	return rows.Err()
+}
+
+// saleUnitFactor returns how many base units one unit of the item holds.
+// The base unit itself, or an empty unit, is 1.
+func saleUnitFactor(ctx context.Context, q querier, itemID, baseUnit, unit string) (float64, error) {
+	if unit == "" || unit == baseUnit {
+		return 1, nil
+	}
+	var factor float64
+	err := q.QueryRow(ctx, "SELECT factor FROM item_units WHERE item_id=$1 AND unit=$2", itemID, unit).Scan(&factor)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, invalidf("unit %s is not allowed for item %s", unit, itemID)
+	}
+	return factor, err
+}